@@ -0,0 +1,217 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// AdaptivePolicy controls how the pool's concurrency is adjusted, see EnableAdaptiveConcurrency;
+// fields left as 0 take the defaults described.
+type AdaptivePolicy struct {
+	Interval       time.Duration // how often the concurrency is adjusted, 1s by default
+	Window         time.Duration // the sliding window the error rate is measured over, 10s by default, at least Interval
+	ErrorThreshold float64       // the error rate, between 0 and 1, above which the concurrency is decreased, 0.1 by default
+	Decrease       float64       // the factor, between 0 and 1, the concurrency is multiplied by when decreased, 0.5 by default
+	Increase       uint          // the # of workers added each Interval whilst the error rate is at or below the threshold, 1 by default
+}
+
+// adaptive is the configuration of the adaptive concurrency controller, guarded by the pool's lock.
+type adaptive struct {
+	min, max uint
+	policy   AdaptivePolicy
+	stop     chan struct{}
+}
+
+// EnableAdaptiveConcurrency has the pool adjust it's # of workers, see Resize, between min and max
+// according to the rate at which it's Work Units fail, see SetErrorClassifier, AIMD style as a
+// congestion controller would; once each Interval the # of workers is multiplied by the policy's
+// Decrease should the error rate over the sliding Window exceed the ErrorThreshold, otherwise,
+// once errors subside, Increase workers are added back. The pool starts at max and the window is
+// cleared after each decrease, so that the same burst of errors isn't acted on more than once,
+// and no adjustment is made whilst no Work Units are completing.
+//
+// Calling it again replaces the policy, calling Resize() whilst enabled is overridden come the
+// next adjustment; see DisableAdaptiveConcurrency.
+func (p *Pool) EnableAdaptiveConcurrency(min, max uint, policy AdaptivePolicy) {
+
+	if min == 0 {
+		panic("invalid min '0'")
+	}
+
+	if max < min {
+		panic(fmt.Sprintf("invalid max '%d'", max))
+	}
+
+	if policy.Interval < 0 {
+		panic(fmt.Sprintf("invalid Interval '%s'", policy.Interval))
+	}
+
+	if policy.Window < 0 {
+		panic(fmt.Sprintf("invalid Window '%s'", policy.Window))
+	}
+
+	if policy.ErrorThreshold < 0 || policy.ErrorThreshold >= 1 {
+		panic(fmt.Sprintf("invalid ErrorThreshold '%v'", policy.ErrorThreshold))
+	}
+
+	if policy.Decrease < 0 || policy.Decrease >= 1 {
+		panic(fmt.Sprintf("invalid Decrease '%v'", policy.Decrease))
+	}
+
+	if policy.Interval == 0 {
+		policy.Interval = time.Second
+	}
+
+	if policy.Window == 0 {
+		policy.Window = time.Second * 10
+	}
+
+	if policy.Window < policy.Interval {
+		policy.Window = policy.Interval
+	}
+
+	if policy.ErrorThreshold == 0 {
+		policy.ErrorThreshold = 0.1
+	}
+
+	if policy.Decrease == 0 {
+		policy.Decrease = 0.5
+	}
+
+	if policy.Increase == 0 {
+		policy.Increase = 1
+	}
+
+	p.m.Lock()
+
+	if p.adaptive != nil {
+		close(p.adaptive.stop)
+	}
+
+	p.adaptive = &adaptive{min: min, max: max, policy: policy, stop: make(chan struct{})}
+
+	if !p.closed {
+		p.startAdaptive()
+	}
+
+	p.m.Unlock()
+
+	p.Resize(max)
+}
+
+// DisableAdaptiveConcurrency stops the pool adjusting it's # of workers, see
+// EnableAdaptiveConcurrency, leaving it at whatever it was last adjusted to.
+func (p *Pool) DisableAdaptiveConcurrency() {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.adaptive != nil {
+		close(p.adaptive.stop)
+		p.adaptive = nil
+	}
+}
+
+// sample is the # of Work Units completed, and of those failed, within one Interval.
+type sample struct {
+	completed, failed int64
+}
+
+// startAdaptive starts the goroutine adjusting the pool's concurrency, which exits when the pool
+// is closed or the adaptive concurrency disabled or replaced; must be called with the lock held.
+func (p *Pool) startAdaptive() {
+
+	a, cancel := p.adaptive, p.cancel
+	stop := a.stop
+
+	go func() {
+
+		t := p.clk().NewTicker(a.policy.Interval)
+		defer t.Stop()
+
+		window := make([]sample, (a.policy.Window+a.policy.Interval-1)/a.policy.Interval)
+		var last sample
+		var i int
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-cancel:
+				return
+			case <-t.C():
+			}
+
+			s := p.Stats()
+			current := sample{completed: s.CompletedCount, failed: s.ErroredCount}
+
+			// the counters were reset along with the pool
+			if current.completed < last.completed {
+				last = sample{}
+			}
+
+			window[i%len(window)] = sample{completed: current.completed - last.completed, failed: current.failed - last.failed}
+			last = current
+			i++
+
+			var total sample
+
+			for _, w := range window {
+				total.completed += w.completed
+				total.failed += w.failed
+			}
+
+			if total.completed == 0 {
+				continue
+			}
+
+			p.m.RLock()
+			workers := p.workers
+			p.m.RUnlock()
+
+			limit := workers
+			rate := float64(total.failed) / float64(total.completed)
+
+			if rate > a.policy.ErrorThreshold {
+
+				limit = uint(float64(workers) * a.policy.Decrease)
+
+				// acted on, so the same errors don't decrease it again
+				for j := range window {
+					window[j] = sample{}
+				}
+
+			} else {
+				limit += a.policy.Increase
+			}
+
+			if limit < a.min {
+				limit = a.min
+			}
+
+			if limit > a.max {
+				limit = a.max
+			}
+
+			if limit == workers {
+				continue
+			}
+
+			if p.logging() {
+				p.logf("pool: adaptive concurrency resizing from %d to %d workers, error rate %.2f", workers, limit, rate)
+			}
+
+			p.m.Lock()
+
+			// disabled or replaced whilst deciding
+			if p.adaptive != a {
+				p.m.Unlock()
+				return
+			}
+
+			p.m.Unlock()
+
+			p.Resize(limit)
+		}
+	}()
+}
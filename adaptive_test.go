@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+	"gopkg.in/go-playground/pool.v2/clock"
+)
+
+func TestEnableAdaptiveConcurrency(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	fake := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	pool.SetClock(fake)
+
+	pool.EnableAdaptiveConcurrency(1, 8, AdaptivePolicy{
+		Interval:       time.Second,
+		Window:         time.Second * 3,
+		ErrorThreshold: 0.2,
+		Decrease:       0.5,
+		Increase:       1,
+	})
+
+	Equal(t, pool.Snapshot().Size, uint(8))
+
+	failed := errors.New("downstream unavailable")
+
+	run := func(err error) {
+
+		units := make([]*WorkUnit, 10)
+
+		for i := range units {
+			units[i] = pool.Queue(func() (interface{}, error) { return nil, err })
+		}
+
+		WaitAll(units...)
+	}
+
+	// waits for the controller to act on the tick
+	tick := func(expected uint) {
+
+		unchanged := pool.Snapshot().Size == expected
+
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+
+		// nothing to wait on, so give it time to not change
+		if unchanged {
+			time.Sleep(time.Millisecond * 20)
+		}
+
+		for i := 0; pool.Snapshot().Size != expected && i < 1000; i++ {
+			time.Sleep(time.Millisecond)
+		}
+
+		Equal(t, pool.Snapshot().Size, expected)
+	}
+
+	// a burst of errors
+	run(failed)
+	tick(4)
+
+	run(failed)
+	tick(2)
+
+	run(failed)
+	tick(1)
+
+	// no lower than min
+	run(failed)
+	tick(1)
+
+	// the errors subside
+	for expected := uint(2); expected <= 8; expected++ {
+		run(nil)
+		tick(expected)
+	}
+
+	// no higher than max
+	run(nil)
+	tick(8)
+
+	pool.DisableAdaptiveConcurrency()
+
+	run(failed)
+	fake.Advance(time.Second)
+	time.Sleep(time.Millisecond * 20)
+	Equal(t, pool.Snapshot().Size, uint(8))
+
+	PanicMatches(t, func() { pool.EnableAdaptiveConcurrency(0, 8, AdaptivePolicy{}) }, "invalid min '0'")
+	PanicMatches(t, func() { pool.EnableAdaptiveConcurrency(4, 2, AdaptivePolicy{}) }, "invalid max '2'")
+	PanicMatches(t, func() { pool.EnableAdaptiveConcurrency(1, 2, AdaptivePolicy{Decrease: 1}) }, "invalid Decrease '1'")
+	PanicMatches(t, func() { pool.EnableAdaptiveConcurrency(1, 2, AdaptivePolicy{ErrorThreshold: -1}) }, "invalid ErrorThreshold '-1'")
+}
+
+func TestEnableAdaptiveConcurrencyClosed(t *testing.T) {
+
+	pool := New(2)
+	pool.Close()
+
+	// enabled whilst closed, replaced and disabled without having started
+	pool.EnableAdaptiveConcurrency(1, 4, AdaptivePolicy{})
+	pool.EnableAdaptiveConcurrency(1, 4, AdaptivePolicy{})
+	pool.DisableAdaptiveConcurrency()
+
+	pool.EnableAdaptiveConcurrency(1, 4, AdaptivePolicy{})
+	pool.Reset()
+	defer pool.Close()
+
+	Equal(t, pool.Snapshot().Size, uint(4))
+	pool.DisableAdaptiveConcurrency()
+}
@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"container/heap"
+	"sync/atomic"
+)
+
+// QueueAffinity queues the work to be run, and starts processing immediately, on the worker
+// chosen by the key, so that all Work Units with the same key run on the same worker, eg. one
+// holding a warmed up cache; trading some load balancing for locality. Each worker has it's own
+// queue for such work, which it runs before any other queued work. The key is mapped to a worker
+// by the pool's current size, so should the pool be Resize()d subsequent work for the key may go
+// to a different worker; the queues of workers removed by shrinking are moved to the shared queue
+// for any worker to run. Priorities don't apply and, when bounded, whether there is room follows
+// the same rules as Queue(), blocking until there is, with the work queued on the workers' own
+// queues counting towards the bound, see NewBounded. Should the WorkFunc return ErrRequeue the
+// Work Unit goes back on to the queue of the same worker.
+func (p *Pool) QueueAffinity(key uint64, fn WorkFunc) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:   make(chan struct{}),
+		fn:     fn,
+		pinned: true,
+		key:    key,
+	})
+}
+
+// pinKey adds the Work Unit to the queue of the worker chosen by it's key, must be called with
+// the lock held and only when there are workers.
+func (p *Pool) pinKey(wu *WorkUnit) {
+	p.pin(p.slots[wu.key%uint64(len(p.slots))], wu)
+
+	// only the chosen worker will take it
+	p.cond.Broadcast()
+}
+
+// pin adds the Work Unit to the tail of the worker's queue, must be called with the lock held.
+func (p *Pool) pin(w *worker, wu *WorkUnit) {
+	wu.offHeap = true
+	w.local = append(w.local, wu)
+	p.local++
+	atomic.AddInt64(&p.stats.Load().pending, 1)
+}
+
+// unpin moves the worker's affinity queue to the shared queue, must be called with the lock held.
+func (p *Pool) unpin(w *worker) {
+
+	for _, wu := range w.local {
+		wu.offHeap = false
+		p.sequence(wu)
+		heap.Push(&p.queue, wu)
+	}
+
+	p.local -= len(w.local)
+	w.local = nil
+}
@@ -0,0 +1,222 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueAffinity(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.Events()
+
+	fn := func(key uint64) WorkFunc {
+		return func() (interface{}, error) {
+			return key, nil
+		}
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 20; i++ {
+		for key := uint64(0); key < 8; key++ {
+			res = append(res, pool.QueueAffinity(key, fn(key)))
+		}
+	}
+
+	workers := workersByKey(t, pool, res)
+
+	// each key only ever ran on the one worker, keys 4 apart share a worker
+	for key := uint64(0); key < 8; key++ {
+		Equal(t, len(workers[key]), 1)
+	}
+
+	for id := range workers[0] {
+		_, ok := workers[4][id]
+		Equal(t, ok, true)
+	}
+
+	// keys that don't share a worker
+	for id := range workers[0] {
+		_, ok := workers[1][id]
+		Equal(t, ok, false)
+	}
+
+	// the queue of a worker removed by shrinking is run by the others
+	pool.Pause()
+
+	res = res[:0]
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.QueueAffinity(3, fn(3)))
+	}
+
+	cancelled := pool.QueueAffinity(3, fn(3))
+	cancelled.Cancel()
+
+	pool.Resize(2)
+	pool.Resume()
+
+	done := make(chan struct{})
+
+	go func() {
+		WaitAll(res...)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("affinity queue lost when shrinking")
+	}
+
+	for _, wu := range res {
+		Equal(t, wu.Error, nil)
+	}
+
+	_, ok := cancelled.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	<-pool.Idle()
+
+	// cancelled when closed
+	pool.Pause()
+
+	res = res[:0]
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.QueueAffinity(uint64(i), fn(0)))
+	}
+
+	pool.Close()
+
+	for _, wu := range res {
+		<-wu.Done
+		_, ok := wu.Error.(*ErrPoolClosed)
+		Equal(t, ok, true)
+	}
+
+	<-pool.Idle()
+
+	wu := pool.QueueAffinity(1, fn(1))
+	<-wu.Done
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+}
+
+func TestQueueAffinityBounded(t *testing.T) {
+
+	pool := NewBounded(2, 2)
+	defer pool.Close()
+
+	pool.Events()
+	pool.Pause()
+
+	fn := func() (interface{}, error) { return uint64(0), nil }
+
+	a := pool.QueueAffinity(0, fn)
+	b := pool.QueueAffinity(0, fn)
+
+	// the work on the workers' own queues counts towards the bound
+	_, ok := pool.TryQueue(func() (interface{}, error) { return nil, nil })
+	Equal(t, ok, false)
+
+	// blocks until there's room rather than being queued elsewhere
+	queued := make(chan *WorkUnit)
+
+	go func() {
+		queued <- pool.QueueAffinity(0, fn)
+	}()
+
+	select {
+	case <-queued:
+		t.Fatal("QueueAffinity should block whilst the queue is full")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	pool.Resume()
+
+	c := <-queued
+
+	workers := workersByKey(t, pool, []*WorkUnit{a, b, c})
+	Equal(t, len(workers[0]), 1)
+}
+
+func TestQueueAffinityRequeue(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.Events()
+
+	var res []*WorkUnit
+
+	for i := 0; i < 10; i++ {
+
+		var runs int
+
+		res = append(res, pool.QueueAffinity(1, func() (interface{}, error) {
+
+			if runs++; runs < 3 {
+				return nil, ErrRequeue
+			}
+
+			return uint64(1), nil
+		}))
+	}
+
+	// requeued back on to the same worker
+	workers := workersByKey(t, pool, res)
+	Equal(t, len(workers[1]), 1)
+
+	for _, wu := range res {
+		Equal(t, wu.Requeues(), 2)
+	}
+}
+
+// workersByKey waits on the Work Units, all of which must return their key, and returns the IDs
+// of the workers each key ran on, from the pool's Started events; so Events() must have been
+// enabled before they were queued.
+func workersByKey(t *testing.T, pool *Pool, units []*WorkUnit) map[uint64]map[int]struct{} {
+
+	WaitAll(units...)
+
+	keys := make(map[uint64]uint64)
+
+	for _, wu := range units {
+		Equal(t, wu.Error, nil)
+		keys[wu.ID()] = wu.Value.(uint64)
+	}
+
+	workers := make(map[uint64]map[int]struct{})
+	seen := make(map[uint64]struct{})
+
+	events := pool.Events()
+
+	// sent before each run, including those that were requeued, so all are buffered by now
+	for n := len(events); n > 0; n-- {
+
+		e := <-events
+		key, ok := keys[e.UnitID]
+
+		if !ok || e.Type != EventStarted {
+			continue
+		}
+
+		if workers[key] == nil {
+			workers[key] = make(map[int]struct{})
+		}
+
+		workers[key][e.WorkerID] = struct{}{}
+		seen[e.UnitID] = struct{}{}
+	}
+
+	Equal(t, len(seen), len(units))
+	Equal(t, pool.DroppedEvents(), uint64(0))
+
+	return workers
+}
@@ -0,0 +1,114 @@
+package pool
+
+import "sync"
+
+// barriers tracks the # of Work Units outstanding per generation, a new generation starting
+// with each Barrier(), so that a barrier can be signalled once every generation up to and
+// including it's own has completed.
+type barriers struct {
+	m           sync.Mutex
+	gen         uint64
+	outstanding map[uint64]int
+	waiting     []barrier // in generation order
+}
+
+type barrier struct {
+	gen uint64
+	ch  chan struct{}
+}
+
+// Whether a Work Unit is counted towards a barrier generation, guarded by the barriers' lock.
+const (
+	uncounted uint8 = iota
+	counted
+	uncountable // completed, whether or not it was counted, so it's never counted again
+)
+
+// stamp counts the Work Unit towards the current generation, once it's been accepted, unless
+// it's already been counted or has completed in the meantime, eg. cancelled whilst being queued.
+func (p *Pool) stamp(wu *WorkUnit) {
+
+	b := &p.barriers
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if wu.counted != uncounted {
+		return
+	}
+
+	if b.outstanding == nil {
+		b.outstanding = make(map[uint64]int)
+	}
+
+	wu.gen = b.gen
+	wu.counted = counted
+	b.outstanding[b.gen]++
+}
+
+// unstamp is called once the Work Unit completes, signalling any barriers
+// that no longer have anything outstanding.
+func (p *Pool) unstamp(wu *WorkUnit) {
+
+	b := &p.barriers
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	c := wu.counted
+	wu.counted = uncountable
+
+	if c != counted {
+		return
+	}
+
+	if b.outstanding[wu.gen]--; b.outstanding[wu.gen] > 0 {
+		return
+	}
+
+	delete(b.outstanding, wu.gen)
+
+	for len(b.waiting) > 0 && !b.pending(b.waiting[0].gen) {
+		close(b.waiting[0].ch)
+		b.waiting[0] = barrier{}
+		b.waiting = b.waiting[1:]
+	}
+}
+
+// pending reports whether any generation up to and including gen has
+// Work Units outstanding, must be called with the lock held.
+func (b *barriers) pending(gen uint64) bool {
+
+	for g := range b.outstanding {
+		if g <= gen {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Barrier returns a channel that is closed once all of the Work Units queued on the pool before
+// it was called have completed, however that may be including being cancelled; work queued
+// afterwards isn't waited on and still runs as usual, so unlike Drain() the pool continues to
+// accept work, eg. for checkpointing a streaming pipeline. Work waiting on a batch's concurrency
+// limit, see BatchWithConcurrency, hasn't been queued on the pool yet so isn't waited on.
+func (p *Pool) Barrier() <-chan struct{} {
+
+	b := &p.barriers
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	gen := b.gen
+	b.gen++
+
+	if !b.pending(gen) {
+		return closedCh
+	}
+
+	ch := make(chan struct{})
+	b.waiting = append(b.waiting, barrier{gen: gen, ch: ch})
+
+	return ch
+}
@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestBarrier(t *testing.T) {
+
+	pool := New(5)
+	defer pool.Close()
+
+	// nothing outstanding
+	<-pool.Barrier()
+
+	gates := make([]chan struct{}, 3)
+
+	for i := range gates {
+		gates[i] = make(chan struct{})
+	}
+
+	blocked := func(gate chan struct{}) WorkFunc {
+		return func() (interface{}, error) {
+			<-gate
+			return nil, nil
+		}
+	}
+
+	first := []*WorkUnit{pool.Queue(blocked(gates[0])), pool.Queue(blocked(gates[0]))}
+	b1 := pool.Barrier()
+
+	second := []*WorkUnit{pool.Queue(blocked(gates[1])), pool.Queue(blocked(gates[1]))}
+	b2 := pool.Barrier()
+
+	third := pool.Queue(blocked(gates[2]))
+
+	isClosed := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		case <-time.After(time.Millisecond * 20):
+			return false
+		}
+	}
+
+	Equal(t, isClosed(b1), false)
+
+	// later generations finishing first doesn't release earlier barriers
+	close(gates[1])
+	WaitAll(second...)
+
+	Equal(t, isClosed(b1), false)
+	Equal(t, isClosed(b2), false)
+
+	close(gates[0])
+	WaitAll(first...)
+
+	Equal(t, isClosed(b1), true)
+	Equal(t, isClosed(b2), true)
+
+	// work queued after the barrier isn't waited on
+	Equal(t, third.IsDone(), false)
+
+	b3 := pool.Barrier()
+	Equal(t, isClosed(b3), false)
+
+	close(gates[2])
+	<-third.Done
+	<-b3
+
+	// cancelled work counts as complete
+	pool.Pause()
+
+	wu := pool.Queue(func() (interface{}, error) { return nil, nil })
+	b4 := pool.Barrier()
+	Equal(t, isClosed(b4), false)
+
+	wu.Cancel()
+	<-b4
+
+	pool.Resume()
+
+	// rapidly interleaved
+	for i := 0; i < 200; i++ {
+
+		wu := pool.Queue(func() (interface{}, error) { return nil, nil })
+		b := pool.Barrier()
+		next := pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond)
+			return nil, nil
+		})
+
+		<-b
+		Equal(t, wu.IsDone(), true)
+		<-next.Done
+	}
+
+	// closing completes everything outstanding
+	pool.Pause()
+	pool.Queue(func() (interface{}, error) { return nil, nil })
+	b5 := pool.Barrier()
+	pool.Close()
+	<-b5
+
+	pool.barriers.m.Lock()
+	Equal(t, len(pool.barriers.outstanding), 0)
+	Equal(t, len(pool.barriers.waiting), 0)
+	pool.barriers.m.Unlock()
+}
+
+func TestBarrierCancelledWhilstQueuing(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	// the batch's Work Units are queued on the pool as it's being cancelled
+	for i := 0; i < 50; i++ {
+
+		batch := pool.BatchWithConcurrency(1)
+
+		for j := 0; j < 20; j++ {
+			batch.Queue(func() (interface{}, error) { return nil, nil })
+		}
+
+		batch.QueueComplete()
+		batch.Cancel()
+		batch.Wait()
+	}
+
+	select {
+	case <-pool.Barrier():
+	case <-time.After(time.Second * 5):
+		t.Fatal("Barrier never returned")
+	}
+
+	pool.barriers.m.Lock()
+	Equal(t, len(pool.barriers.outstanding), 0)
+	pool.barriers.m.Unlock()
+}
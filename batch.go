@@ -1,18 +1,67 @@
 package pool
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gopkg.in/go-playground/pool.v2/clock"
+)
 
 // Batch contains all information for a batch run of WorkUnits
 type Batch struct {
-	pool    *Pool
-	m       *sync.Mutex
-	units   []*WorkUnit
-	results chan *WorkUnit
-	done    chan struct{}
-	closed  bool
-	wg      *sync.WaitGroup
+	pool          *Pool
+	m             *sync.Mutex
+	units         []*WorkUnit
+	results       chan *WorkUnit
+	done          chan struct{}
+	abandoned     chan struct{}
+	closed        bool
+	cancelled     bool
+	sequential    bool
+	turn          chan struct{} // closed once the last Work Unit queued is done, see Sequential
+	wg            *sync.WaitGroup
+	once          *sync.Once
+	sem           chan struct{}
+	cancelOnError bool
+	err           error
+	progress      func(completed, total int)
+	pm            *sync.Mutex
+	completed     int
+	timeout       time.Duration
+	timer         clock.Timer
+	last          time.Time
+	policy        BatchAbandonPolicy
+	abandonAfter  time.Duration
+	ao            *sync.Once
+	middleware    []Middleware
+	upstream      []*Batch // cancelled along with the batch, see ThenBatch
+	bounded       bool     // whether the workers wait for the results to be delivered, see SetResultBuffer
+	read          bool     // whether the Results() channel has been handed out, see SetResultBuffer
+	flow          *flow    // it's share of the pool, see NewFair
 }
 
+// BatchAbandonPolicy controls what happens to a batch's results once they're no longer
+// being read from the Results() channel, see SetAbandonPolicy.
+type BatchAbandonPolicy uint8
+
+// Batch abandon policies
+const (
+	// Block waits for the results to be read, forever if need be, leaving the goroutine delivering
+	// each undelivered result blocked, and the batch's work continuing to run, should the reader
+	// stop early, eg. breaking out of a range over Results().
+	Block BatchAbandonPolicy = iota
+
+	// AutoCancel cancels the batch's remaining Work Units once a result hasn't been read in time,
+	// discarding the rest of the results.
+	AutoCancel
+
+	// Discard leaves the batch's work to run, dropping each result that isn't read in time.
+	Discard
+)
+
 // Batch creates a new Batch object for queueing Work Units separate from any others
 // that may be running on the pool. Grouping these Work Units together allows for individual
 // Cancellation of the Batch Work Units without affecting anything else running on the pool
@@ -20,54 +69,523 @@ type Batch struct {
 // NOTE: Batch is not reusable, once QueueComplete() has been called it's lifetime has been sealed
 // to completing the Queued items.
 func (p *Pool) Batch() *Batch {
-	return &Batch{
-		pool:    p,
-		m:       new(sync.Mutex),
-		units:   make([]*WorkUnit, 0, 4), // capacity it to 4 so it doesn't grow and allocate too many times.
-		results: make(chan *WorkUnit),
-		done:    make(chan struct{}),
-		wg:      new(sync.WaitGroup),
+
+	b := &Batch{
+		pool:      p,
+		m:         new(sync.Mutex),
+		units:     make([]*WorkUnit, 0, 4), // capacity it to 4 so it doesn't grow and allocate too many times.
+		results:   make(chan *WorkUnit),
+		done:      make(chan struct{}),
+		abandoned: make(chan struct{}),
+		wg:        new(sync.WaitGroup),
+		once:      new(sync.Once),
+		pm:        new(sync.Mutex),
+		ao:        new(sync.Once),
+		flow:      &flow{weight: 1},
+	}
+
+	// tracked until it's results have been read, see Reset
+	p.m.Lock()
+
+	if p.batches == nil {
+		p.batches = make(map[*Batch]struct{})
+	}
+
+	p.batches[b] = struct{}{}
+	p.m.Unlock()
+
+	return b
+}
+
+// BatchWithConcurrency creates a new Batch, see Batch(), that allows at most n of it's
+// Work Units to execute simultaneously even when the pool has idle workers; useful when
+// sharing a large pool but the batch talks to something rate limited.
+// Work Units waiting on the limit are not queued on the pool until they can run.
+func (p *Pool) BatchWithConcurrency(n uint) *Batch {
+
+	if n == 0 {
+		panic("invalid concurrency '0'")
+	}
+
+	b := p.Batch()
+	b.sem = make(chan struct{}, n)
+
+	return b
+}
+
+// RunBatch is the recommended way to run a batch when all of the work is known up front, it
+// queues all of the work on a new Batch, calls QueueComplete() and returns the Results() channel
+// along with a function that Cancel()s the batch. At most concurrency of the Work Units execute
+// simultaneously, see BatchWithConcurrency, a concurrency of 0 imposes no limit beyond the pool's.
+func (p *Pool) RunBatch(concurrency uint, fns []WorkFunc) (<-chan *WorkUnit, func()) {
+
+	var b *Batch
+
+	if concurrency == 0 {
+		b = p.Batch()
+	} else {
+		b = p.BatchWithConcurrency(concurrency)
+	}
+
+	b.QueueAll(fns)
+	b.QueueComplete()
+
+	return b.Results(), b.Cancel
+}
+
+// BatchWithTimeout creates a new Batch, see Batch(), that guards against QueueComplete() being
+// forgotten; should nothing be queued and QueueComplete() not be called within d of the last
+// Queue, or of the batch being created, a warning is logged, see SetLogger, and the batch
+// completes itself so that Results() closes rather than blocking forever.
+func (p *Pool) BatchWithTimeout(d time.Duration) *Batch {
+
+	if d <= 0 {
+		panic(fmt.Sprintf("invalid timeout '%s'", d))
+	}
+
+	b := p.Batch()
+	b.timeout = d
+	b.last = p.now()
+	b.timer = p.clk().AfterFunc(d, b.expired)
+
+	return b
+}
+
+// expired auto completes the batch unless more work has been queued since the timer was set,
+// in which case the timer is set again for the remainder of the timeout.
+func (b *Batch) expired() {
+
+	b.m.Lock()
+
+	if b.closed {
+		b.m.Unlock()
+		return
 	}
+
+	if rem := b.timeout - b.pool.now().Sub(b.last); rem > 0 {
+		b.timer.Reset(rem)
+		b.m.Unlock()
+		return
+	}
+
+	b.m.Unlock()
+
+	if b.pool.logging() {
+		b.pool.logf("pool: batch auto completed, QueueComplete() was not called within %s of the last Queue", b.timeout)
+	}
+	b.QueueComplete()
 }
 
 // Queue queues the work to be run in the pool and starts processing immediately
 // and also retains a reference for Cancellation and outputting to results.
 // WARNING be sure to call QueueComplete() once all work has been Queued.
 func (b *Batch) Queue(fn WorkFunc) {
+	b.queue(fn)
+}
+
+// QueueWith is the same as Queue() but also stores the input the work is for, available from
+// the resulting Work Unit's Input(), so that results can be correlated with their inputs.
+func (b *Batch) QueueWith(input interface{}, fn WorkFunc) {
+	b.queueUnit(&WorkUnit{
+		Done:  make(chan struct{}),
+		fn:    fn,
+		input: input,
+	})
+}
+
+// queue returns the queued Work Unit or nil if the batch has already been closed.
+func (b *Batch) queue(fn WorkFunc) *WorkUnit {
+	return b.queueUnit(&WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	})
+}
+
+// QueueChecked is the same as Queue() but returns the queued Work Unit, or an ErrBatchComplete
+// error should QueueComplete() already have been called, or ErrBatchCancelled should the batch
+// have been cancelled, rather than silently ignoring the work; useful when several producers
+// queue on the batch concurrently with it being completed or cancelled.
+func (b *Batch) QueueChecked(fn WorkFunc) (*WorkUnit, error) {
+	return b.queueChecked(&WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	})
+}
+
+// queueUnit returns the queued Work Unit or nil if the batch has already been closed.
+func (b *Batch) queueUnit(wu *WorkUnit) *WorkUnit {
+	wu, _ = b.queueChecked(wu)
+	return wu
+}
+
+func (b *Batch) queueChecked(wu *WorkUnit) (*WorkUnit, error) {
+
+	wu.shared = true
 
 	b.m.Lock()
 
 	if b.closed {
+
+		var err error = &ErrBatchComplete{s: errBatchClosed}
+
+		if b.cancelled {
+			err = &ErrBatchCancelled{s: errBatchCancelled}
+		}
+
 		b.m.Unlock()
-		return
+
+		return nil, err
+	}
+
+	wu.mws = b.middleware
+	wu.flow = b.flow
+
+	var prev, turn chan struct{}
+
+	if b.sequential {
+		prev, turn = b.turn, make(chan struct{})
+		b.turn = turn
 	}
 
-	wu := b.pool.Queue(fn)
+	var delivered chan struct{}
+
+	// run inline the results are delivered once all have run, so there's no waiting on them
+	if b.bounded && !b.pool.inline {
+		delivered = make(chan struct{})
+		wu.delivered = delivered
+	}
+
+	if b.sem == nil && !b.sequential && !b.pool.inline {
+		b.pool.enqueue(wu)
+	} else {
+		// queued on the pool once a concurrency token is acquired, it's turn comes or QueueComplete() is called
+		wu.id = b.pool.nextID()
+		wu.pool = b.pool
+	}
 
 	b.units = append(b.units, wu) // keeping a reference for cancellation purposes
 	b.wg.Add(1)
+
+	if b.timer != nil {
+		b.last = b.pool.now()
+	}
 	b.m.Unlock()
 
+	// run and delivered by QueueComplete()
+	if b.pool.inline {
+		return wu, nil
+	}
+
 	go func(b *Batch, wu *WorkUnit) {
+
+		if turn != nil {
+
+			// the previous Work Unit's done, as are all of those before it
+			if prev != nil {
+				<-prev
+			}
+
+			b.pool.enqueue(wu)
+			<-wu.Done
+			close(turn)
+
+		} else if b.sem != nil && b.dispatch(wu) {
+			<-wu.Done
+			<-b.sem
+		}
+
 		<-wu.Done
-		b.results <- wu
+
+		if b.pool.failure(wu.Error) {
+			b.failed(wu.Error)
+		}
+
+		b.reportProgress(true)
+		b.deliver(wu)
+
+		if delivered != nil {
+			close(delivered)
+		}
+
 		b.wg.Done()
 	}(b, wu)
+
+	return wu, nil
+}
+
+// Sequential sets the batch to run it's Work Units one at a time, in the order queued, even on
+// a pool with many workers; each only being queued on the pool once the one before it is Done,
+// whether it completed or was cancelled, for work with side effects that must happen in order.
+// The results are still output on Results() as they complete; it must be called before any
+// work is queued as work already queued isn't affected.
+func (b *Batch) Sequential() {
+	b.m.Lock()
+	b.sequential = true
+	b.m.Unlock()
+}
+
+// QueueAll queues all of the work to be run in the pool, see Queue().
+// QueueComplete() is not called so more work may still be queued afterwards.
+func (b *Batch) QueueAll(fns []WorkFunc) {
+
+	b.m.Lock()
+
+	// grow once up front rather than on each append
+	if cap(b.units)-len(b.units) < len(fns) {
+		units := make([]*WorkUnit, len(b.units), len(b.units)+len(fns))
+		copy(units, b.units)
+		b.units = units
+	}
+
+	b.m.Unlock()
+
+	for _, fn := range fns {
+		b.queue(fn)
+	}
+}
+
+// QueueChunks splits the items into chunks of at most chunkSize and queues a Work Unit per chunk
+// that calls fn with it, amortizing the per Work Unit overhead across many items, see Queue().
+// The chunks share the items backing array so should not be appended to.
+func (b *Batch) QueueChunks(items []interface{}, chunkSize uint, fn func(chunk []interface{}) (interface{}, error)) {
+
+	if chunkSize == 0 {
+		panic("invalid chunkSize '0'")
+	}
+
+	size := int(chunkSize)
+
+	for i := 0; i < len(items); i += size {
+
+		end := i + size
+
+		if end > len(items) {
+			end = len(items)
+		}
+
+		chunk := items[i:end:end]
+
+		var wf WorkFunc
+
+		// left nil for the Work Unit to report rather than panicking
+		if fn != nil {
+			wf = func() (interface{}, error) {
+				return fn(chunk)
+			}
+		}
+
+		b.queue(wf)
+	}
+}
+
+// dispatch waits for a concurrency token before queuing the Work Unit on the pool, giving up
+// if the Work Unit is cancelled whilst waiting. It returns whether a token was acquired, which
+// must be released once the Work Unit is Done.
+func (b *Batch) dispatch(wu *WorkUnit) bool {
+	select {
+	case b.sem <- struct{}{}:
+		b.pool.enqueue(wu)
+		return true
+	case <-wu.Done:
+		return false
+	}
 }
 
 // QueueComplete lets the batch know that there will be no more Work Units Queued
 // so that it may close the results channels once all work is completed.
+// Only the first call has any effect, it's safe to call any number of times, concurrently and
+// before or after Cancel(), which calls it itself; work queued after it's been called is ignored.
 // WARNING: if this function is not called the results channel will never exhaust,
-// but block forever listening for more results.
+// but block forever listening for more results, see BatchWithTimeout for a safety net.
 func (b *Batch) QueueComplete() {
 	b.m.Lock()
+
+	if b.closed {
+		b.m.Unlock()
+		return
+	}
+
 	b.closed = true
 	close(b.done)
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+
 	b.m.Unlock()
+
+	// now that the total is known
+	b.reportProgress(false)
+
+	if b.pool.inline {
+		b.runInline()
+	}
 }
 
-// Cancel cancells the Work Units belonging to this Batch
+// OnProgress sets a callback that is called each time one of the batch's Work Units completes
+// with the number completed so far and the total number queued, which is -1 until QueueComplete()
+// has been called and the total is known; it's also called once when QueueComplete() is called.
+//
+// WARNING: the callback is called from the result collection path, so must be quick, eg.
+// updating a progress bar, otherwise it holds up the delivery of results.
+func (b *Batch) OnProgress(fn func(completed, total int)) {
+	b.pm.Lock()
+	b.progress = fn
+	b.pm.Unlock()
+}
+
+// reportProgress calls the progress callback, if set, optionally counting one more completed
+// Work Unit; calls are serialized so that completed is seen to only ever increase.
+func (b *Batch) reportProgress(completed bool) {
+
+	b.pm.Lock()
+	defer b.pm.Unlock()
+
+	if completed {
+		b.completed++
+	}
+
+	if b.progress == nil {
+		return
+	}
+
+	total := -1
+
+	b.m.Lock()
+	if b.closed {
+		total = len(b.units)
+	}
+	b.m.Unlock()
+
+	b.pool.guard("OnProgress callback", func() { b.progress(b.completed, total) })
+}
+
+// CancelOnError sets the batch to Cancel() itself as soon as any of it's Work Units
+// returns an error, the error that triggered the cancellation can be retrieved using Err().
+// Results() will still output all Work Units, those cancelled with an ErrCancelled error,
+// and close once done as usual.
+func (b *Batch) CancelOnError() {
+	b.m.Lock()
+	b.cancelOnError = true
+	b.m.Unlock()
+}
+
+// Err returns the error that caused the batch to be cancelled when CancelOnError is set,
+// nil if no Work Unit has failed.
+func (b *Batch) Err() error {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.err
+}
+
+// failed records the first Work Unit error and cancels the batch when CancelOnError is set.
+func (b *Batch) failed(err error) {
+
+	b.m.Lock()
+
+	if !b.cancelOnError || b.err != nil {
+		b.m.Unlock()
+		return
+	}
+
+	b.err = err
+	b.m.Unlock()
+
+	b.Cancel()
+}
+
+// abandon discards the batch's remaining results, closing it's Results() channel when read.
+func (b *Batch) abandon() {
+	b.QueueComplete()
+	b.ao.Do(func() {
+		close(b.abandoned)
+	})
+}
+
+// Use registers middleware that wraps only the batch's WorkFuncs, inside of the pool's middleware,
+// see Pool.Use(), so that the pool's run first; eg. for per batch rate limiting or logging without
+// affecting any other work. As with the pool's middleware the first registered is the outermost.
+// Only work queued afterwards is wrapped, and as batches aren't reusable the middleware is
+// gone along with the batch.
+func (b *Batch) Use(mw ...Middleware) {
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	// copied on write as queued Work Units hold onto the current slice
+	n := make([]Middleware, len(b.middleware), len(b.middleware)+len(mw))
+	copy(n, b.middleware)
+
+	b.middleware = append(n, mw...)
+}
+
+// SetAbandonPolicy sets what happens should a result not be read from the Results() channel
+// within d of the Work Unit completing, the reader then being considered gone, see
+// BatchAbandonPolicy; by default the batch Blocks, leaking a goroutine per undelivered result
+// should the reader stop early. Must be set before any work is queued.
+//
+// NOTE: d should allow for the slowest the reader ever is, a slow reader looks just like one
+// that has stopped.
+func (b *Batch) SetAbandonPolicy(policy BatchAbandonPolicy, d time.Duration) {
+
+	if policy != Block && d <= 0 {
+		panic(fmt.Sprintf("invalid abandon after '%s'", d))
+	}
+
+	b.m.Lock()
+	b.policy, b.abandonAfter = policy, d
+	b.m.Unlock()
+}
+
+// deliver sends the Work Unit to the Results() channel according to the abandon policy.
+func (b *Batch) deliver(wu *WorkUnit) {
+
+	b.m.Lock()
+	policy, d, results := b.policy, b.abandonAfter, b.results
+	b.m.Unlock()
+
+	if policy == Block {
+		select {
+		case results <- wu:
+		case <-b.abandoned:
+		}
+		return
+	}
+
+	t := b.pool.clk().NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case results <- wu:
+	case <-b.abandoned:
+	case <-t.C():
+
+		if policy == AutoCancel {
+			b.Cancel()
+			b.abandon()
+		}
+	}
+}
+
+// Cancel cancells the Work Units belonging to this Batch, first calling QueueComplete() so no
+// more can be queued. It's safe to call any number of times, concurrently and before or after
+// QueueComplete(); Work Units that have already completed are unaffected.
 func (b *Batch) Cancel() {
+	b.CancelCause(nil)
+}
+
+// CancelCause is the same as Cancel() except that the Work Units that are cancelled have their
+// Error set to the cause, rather than a generic ErrCancelled error, so that it's clear why they
+// were cancelled; a nil cause is the same as calling Cancel().
+func (b *Batch) CancelCause(cause error) {
+
+	if cause == nil {
+		cause = &ErrCancelled{s: errCancelled}
+	}
+
+	b.m.Lock()
+	b.cancelled = true
+	b.m.Unlock()
 
 	b.QueueComplete() // no more to be added
 
@@ -76,23 +594,361 @@ func (b *Batch) Cancel() {
 	// go in reverse order to try and cancel as amany as possbile
 	// one at end are less likely to have run than those at the beginning
 	for i := len(b.units) - 1; i >= 0; i-- {
-		b.units[i].Cancel()
+		b.units[i].cancelCause(cause)
 	}
 
+	upstream := b.upstream
 	b.m.Unlock()
+
+	for _, u := range upstream {
+		u.CancelCause(cause)
+	}
 }
 
 // Results returns a Work Unit result channel that will output all
 // completed units of work.
 func (b *Batch) Results() <-chan *WorkUnit {
 
+	b.once.Do(func() {
+		go func(b *Batch) {
+			// no more Work Units can be added once done is closed
+			<-b.done
+			b.wg.Wait()
+
+			b.m.Lock()
+			close(b.results)
+			b.m.Unlock()
+
+			b.pool.m.Lock()
+			delete(b.pool.batches, b)
+			b.pool.m.Unlock()
+		}(b)
+	})
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.read = true
+
+	return b.results
+}
+
+// SetResultBuffer bounds the batch's results, applying backpressure to the pool, so that at most n
+// completed Work Units are buffered by the Results() channel ready to be read; once full each
+// worker completing one of the batch's Work Units waits for it's result to be read, or dropped
+// according to the abandon policy, see SetAbandonPolicy, before moving on to it's next Work Unit,
+// so a buffer of 0 has the workers wait on the consumer for every result. A larger buffer lets the
+// workers keep going whilst the consumer is slow to read. Without it being called the workers never
+// wait, each unread result instead being held by a goroutine until read. It must be called before
+// any work is queued, and before Results() is called, otherwise it has no effect.
+//
+// WARNING: a consumer that stalls holds up the workers, and so any other work on the pool, so
+// should it stop reading early the batch must be cancelled and it's results drained, or an abandon
+// policy set; the batch's results must not be read from a WorkFunc on the same pool.
+// NOTE: OrderedResults() reads from the Results() channel, holding the completed Work Units it
+// can't yet output itself however many there are, so the buffer only sits in front of it; the
+// channel it returns is unbuffered.
+func (b *Batch) SetResultBuffer(n int) {
+
+	if n < 0 {
+		panic(fmt.Sprintf("invalid n '%d'", n))
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	// the channel may already have been handed out
+	if len(b.units) > 0 || b.read {
+		return
+	}
+
+	b.results = make(chan *WorkUnit, n)
+	b.bounded = true
+}
+
+// ResultBuffer returns the # of completed Work Units the Results() channel buffers, see SetResultBuffer.
+func (b *Batch) ResultBuffer() int {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return cap(b.results)
+}
+
+// ResultsCtx is the same as Results() except that should the context be done before all of the
+// results have been consumed the batch is cancelled and the returned channel closed promptly,
+// the remaining results being discarded; so a consumer that stops early doesn't leave the
+// batch's work queued on the pool.
+func (b *Batch) ResultsCtx(ctx context.Context) <-chan *WorkUnit {
+
+	out := make(chan *WorkUnit)
+
 	go func(b *Batch) {
-		<-b.done
-		b.m.Lock()
-		b.wg.Wait()
-		b.m.Unlock()
-		close(b.results)
+
+		defer close(out)
+
+		results := b.Results()
+
+		for {
+			select {
+			case wu, ok := <-results:
+
+				if !ok {
+					return
+				}
+
+				select {
+				case out <- wu:
+					continue
+				case <-ctx.Done():
+				}
+
+			case <-ctx.Done():
+			}
+
+			b.Cancel()
+
+			// drain so that none of the batch's goroutines are left blocked
+			go func() {
+				for range results {
+				}
+			}()
+
+			return
+		}
 	}(b)
 
-	return b.results
+	return out
+}
+
+// MapResults is the same as Results() except that each Work Unit is passed through fn, which
+// may modify it, eg. decoding it's Value, setting it's Error should that fail, or return another in
+// it's place, before being output on the returned channel; the mapping runs once for each Work
+// Unit, in the result collection path, so the results are output in the order they completed as
+// with Results(). Should fn panic the Work Unit is output unmapped with it's Error set to a
+// PanicError. Use either Results, OrderedResults or MapResults, not more than one, for any one batch.
+//
+// WARNING: fn is called from the result collection path, so a slow fn holds up the delivery of results.
+func (b *Batch) MapResults(fn func(wu *WorkUnit) *WorkUnit) <-chan *WorkUnit {
+
+	mapped := make(chan *WorkUnit)
+
+	go func(b *Batch) {
+
+		defer close(mapped)
+
+		for wu := range b.Results() {
+			mapped <- b.mapResult(wu, fn)
+		}
+	}(b)
+
+	return mapped
+}
+
+// mapResult returns the Work Unit mapped by fn, see MapResults.
+func (b *Batch) mapResult(wu *WorkUnit, fn func(wu *WorkUnit) *WorkUnit) (mapped *WorkUnit) {
+
+	defer func() {
+		if err := recover(); err != nil {
+			wu.Error = b.pool.recoveryError(wu, err)
+			mapped = wu
+		}
+	}()
+
+	return fn(wu)
+}
+
+// ForEach calls fn with each of the batch's Work Units as they complete, in completion order,
+// blocking until all have completed; fn is only ever called from the calling goroutine, never
+// concurrently. Cancelled Work Units are passed to fn as usual with an ErrCancelled error.
+//
+// NOTE: a slow fn holds up the draining of the batch's results.
+// WARNING: QueueComplete() must be called, otherwise this blocks forever.
+func (b *Batch) ForEach(fn func(wu *WorkUnit)) {
+	for wu := range b.Results() {
+		b.pool.guard("ForEach callback", func() { fn(wu) })
+	}
+}
+
+// WaitAndCollect blocks until all of the batch's Work Units have completed, draining the
+// results channel, and returns the non-nil errors in the order the Work Units completed, leaving
+// out those that aren't failures, see SetErrorClassifier.
+//
+// WARNING: QueueComplete() is not called, all work must have been queued and QueueComplete()
+// called beforehand, otherwise this blocks forever.
+func (b *Batch) WaitAndCollect() []error {
+
+	var errs []error
+
+	for wu := range b.Results() {
+		if b.pool.failure(wu.Error) {
+			errs = append(errs, wu.Error)
+		}
+	}
+
+	return errs
+}
+
+// BatchResult is a summary of a finished batch, see Batch.Wait().
+type BatchResult struct {
+	Total     int         // # of Work Units queued
+	Succeeded int         // # of Work Units that completed without error, or with one that isn't a failure
+	Failed    int         // # of Work Units that failed, including being cancelled, see SetErrorClassifier
+	Failures  []*WorkUnit // the Work Units that failed, in the order they were queued
+}
+
+// Err returns all of the failures' errors joined together using errors.Join,
+// nil if there were none.
+func (r BatchResult) Err() error {
+
+	if len(r.Failures) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(r.Failures))
+
+	for i, wu := range r.Failures {
+		errs[i] = wu.Error
+	}
+
+	return errors.Join(errs...)
+}
+
+// Wait blocks until all of the batch's Work Units have completed, draining the results channel,
+// and returns a summary of them. It's safe to call once the results have already been read, or
+// more than once, as the summary is built from all of the batch's Work Units rather than those
+// read by Wait.
+//
+// WARNING: QueueComplete() is not called, all work must have been queued and QueueComplete()
+// called beforehand, otherwise this blocks forever.
+func (b *Batch) Wait() BatchResult {
+
+	// blocks until closed, which is only once all Work Units are done, whoever reads them
+	for range b.Results() {
+	}
+
+	b.m.Lock()
+	units := b.units
+	b.m.Unlock()
+
+	r := BatchResult{Total: len(units)}
+
+	for _, wu := range units {
+
+		if b.pool.failure(wu.Error) {
+			r.Failed++
+			r.Failures = append(r.Failures, wu)
+			continue
+		}
+
+		r.Succeeded++
+	}
+
+	return r
+}
+
+// TakeN reads the batch's results until n Work Units have completed without failing, eg. the
+// fastest n of several redundant requests, then cancels the rest of the batch and returns those
+// n in the order they completed; Work Units with an error that's a failure, see SetErrorClassifier,
+// don't count towards n. Should fewer than n succeed those that did are returned once all have
+// completed. The remaining results are drained before returning so no goroutine is left blocked
+// delivering them.
+//
+// WARNING: QueueComplete() is not called, all work must have been queued and QueueComplete()
+// called beforehand, otherwise this blocks forever when fewer than n succeed.
+func (b *Batch) TakeN(n int) []*WorkUnit {
+
+	if n <= 0 {
+		panic(fmt.Sprintf("invalid n '%d'", n))
+	}
+
+	taken := make([]*WorkUnit, 0, n)
+	results := b.Results()
+
+	for wu := range results {
+
+		if b.pool.failure(wu.Error) {
+			continue
+		}
+
+		if taken = append(taken, wu); len(taken) == n {
+			b.Cancel()
+			break
+		}
+	}
+
+	for range results {
+	}
+
+	return taken
+}
+
+// MergeResults fans in the results of all the batches into a single channel, which is closed
+// once every batch's results have been output; cancelling a batch doesn't hold up the merge
+// as it's cancelled Work Units are still output, with an ErrCancelled error, as usual.
+// As with Results() QueueComplete() must be called for each batch.
+func MergeResults(batches ...*Batch) <-chan *WorkUnit {
+
+	merged := make(chan *WorkUnit)
+	wg := new(sync.WaitGroup)
+	wg.Add(len(batches))
+
+	for _, b := range batches {
+		go func(b *Batch) {
+			for wu := range b.Results() {
+				merged <- wu
+			}
+			wg.Done()
+		}(b)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
+
+// OrderedResults returns a Work Unit result channel that outputs all completed units of work
+// in the exact order they were Queued, rather than the order they completed.
+// Use either Results or OrderedResults, not both, for any one batch.
+//
+// NOTE: completed Work Units are held in memory until all those queued before them have
+// completed, so a slow early Work Unit can hold back many completed later ones.
+func (b *Batch) OrderedResults() <-chan *WorkUnit {
+
+	ordered := make(chan *WorkUnit)
+
+	go func(b *Batch) {
+
+		var next int
+		completed := make(map[*WorkUnit]struct{})
+
+		for wu := range b.Results() {
+
+			completed[wu] = struct{}{}
+
+			// output as many as are now in sequence
+			for {
+				b.m.Lock()
+				if next == len(b.units) {
+					b.m.Unlock()
+					break
+				}
+				u := b.units[next]
+				b.m.Unlock()
+
+				if _, ok := completed[u]; !ok {
+					break
+				}
+
+				delete(completed, u)
+				ordered <- u
+				next++
+			}
+		}
+
+		close(ordered)
+	}(b)
+
+	return ordered
 }
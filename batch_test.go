@@ -1,6 +1,12 @@
 package pool
 
 import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -135,3 +141,1405 @@ func TestBatchCancelItemsCancelledAfterward(t *testing.T) {
 
 	Equal(t, count, 40)
 }
+
+func TestBatchWithConcurrency(t *testing.T) {
+
+	var running, max int32
+
+	fn := func() (interface{}, error) {
+
+		n := atomic.AddInt32(&running, 1)
+
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond * 20)
+		atomic.AddInt32(&running, -1)
+
+		return 1, nil
+	}
+
+	pool := New(8)
+	defer pool.Close()
+
+	batch := pool.BatchWithConcurrency(2)
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(fn)
+	}
+
+	batch.QueueComplete()
+
+	var count int
+
+	for wu := range batch.Results() {
+		Equal(t, wu.Error, nil)
+		count += wu.Value.(int)
+	}
+
+	Equal(t, count, 10)
+	Equal(t, atomic.LoadInt32(&max), int32(2))
+
+	PanicMatches(t, func() { pool.BatchWithConcurrency(0) }, "invalid concurrency '0'")
+}
+
+func TestBatchWithConcurrencyCancel(t *testing.T) {
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 100)
+		return 1, nil
+	}
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.BatchWithConcurrency(1)
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(fn)
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	batch.Cancel()
+
+	var count, cancelled int
+
+	for wu := range batch.Results() {
+		count++
+		if _, ok := wu.Error.(*ErrCancelled); ok {
+			cancelled++
+		}
+	}
+
+	Equal(t, count, 10)
+	Equal(t, cancelled, 9)
+
+	// no tokens leaked, all are available again
+	Equal(t, len(batch.sem), 0)
+}
+
+func TestBatchCancelOnError(t *testing.T) {
+
+	var executed int32
+
+	bad := errors.New("unit 5 failed")
+
+	newFunc := func(i int) WorkFunc {
+		return func() (interface{}, error) {
+			atomic.AddInt32(&executed, 1)
+			if i == 5 {
+				return nil, bad
+			}
+			time.Sleep(time.Millisecond * 10)
+			return i, nil
+		}
+	}
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+	batch.CancelOnError()
+
+	go func() {
+		for i := 0; i < 1000; i++ {
+			batch.Queue(newFunc(i))
+		}
+		batch.QueueComplete()
+	}()
+
+	var count int
+
+	for range batch.Results() {
+		count++
+	}
+
+	Equal(t, batch.Err(), bad)
+	Equal(t, atomic.LoadInt32(&executed) < 100, true)
+
+	// cancelled Work Units are still output on Results
+	Equal(t, count >= int(atomic.LoadInt32(&executed)), true)
+
+	// without CancelOnError an error doesn't affect the rest of the batch
+	batch = pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(newFunc(i))
+	}
+
+	batch.QueueComplete()
+
+	count = 0
+
+	for range batch.Results() {
+		count++
+	}
+
+	Equal(t, count, 10)
+	Equal(t, batch.Err(), nil)
+}
+
+func TestBatchOrderedResults(t *testing.T) {
+
+	newFunc := func(i int, d time.Duration) WorkFunc {
+		return func() (interface{}, error) {
+			time.Sleep(d)
+			return i, nil
+		}
+	}
+
+	pool := New(8)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 50; i++ {
+		batch.Queue(newFunc(i, time.Millisecond*time.Duration(rand.Intn(50))))
+	}
+
+	batch.QueueComplete()
+
+	var order []int
+
+	for wu := range batch.OrderedResults() {
+		order = append(order, wu.Value.(int))
+	}
+
+	Equal(t, len(order), 50)
+
+	for i, v := range order {
+		Equal(t, v, i)
+	}
+}
+
+func TestBatchQueueAll(t *testing.T) {
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	batch.QueueAll([]WorkFunc{fn, fn, fn, fn, fn, fn})
+	Equal(t, len(batch.units), 6)
+
+	// can still queue more afterwards
+	batch.Queue(fn)
+	batch.QueueComplete()
+
+	var count int
+
+	for wu := range batch.Results() {
+		count += wu.Value.(int)
+	}
+
+	Equal(t, count, 7)
+}
+
+func TestBatchOnProgress(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	var completed []int
+	var totals []int
+
+	batch := pool.Batch()
+	batch.OnProgress(func(c, total int) {
+		completed = append(completed, c)
+		totals = append(totals, total)
+	})
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond * 10)
+			return nil, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	for range batch.Results() {
+	}
+
+	// 10 completions + QueueComplete
+	Equal(t, len(completed), 11)
+	Equal(t, completed[len(completed)-1], 10)
+	Equal(t, totals[len(totals)-1], 10)
+
+	for i := 1; i < len(completed); i++ {
+		Equal(t, completed[i] >= completed[i-1], true)
+	}
+
+	// total unknown until QueueComplete
+	var total int
+
+	batch = pool.Batch()
+	batch.OnProgress(func(c, t int) {
+		total = t
+	})
+
+	wu := batch.queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+	results := batch.Results()
+	<-results
+
+	Equal(t, total, -1)
+
+	batch.QueueComplete()
+	<-results
+
+	Equal(t, total, 1)
+}
+
+func TestBatchWaitAndCollect(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+			if i%2 == 0 {
+				return nil, errors.New("failed")
+			}
+			return i, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	errs := batch.WaitAndCollect()
+	Equal(t, len(errs), 5)
+
+	for _, err := range errs {
+		Equal(t, err.Error(), "failed")
+	}
+
+	// results were drained
+	_, ok := <-batch.Results()
+	Equal(t, ok, false)
+
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+
+	Equal(t, len(batch.WaitAndCollect()), 0)
+}
+
+func TestBatchWithTimeout(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	logger := new(testLogger)
+	pool.SetLogger(logger)
+
+	batch := pool.BatchWithTimeout(time.Millisecond * 100)
+
+	for i := 0; i < 4; i++ {
+		batch.Queue(func() (interface{}, error) { return nil, nil })
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	var count int
+
+	// QueueComplete not called
+	for range batch.Results() {
+		count++
+	}
+
+	Equal(t, count, 4)
+
+	logger.m.Lock()
+	Equal(t, len(logger.msgs), 1)
+	MatchRegex(t, logger.msgs[0], "QueueComplete\\(\\) was not called within 100ms")
+	logger.m.Unlock()
+
+	// nothing logged when completed in time
+	batch = pool.BatchWithTimeout(time.Millisecond * 50)
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+
+	for range batch.Results() {
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	logger.m.Lock()
+	Equal(t, len(logger.msgs), 1)
+	logger.m.Unlock()
+
+	PanicMatches(t, func() { pool.BatchWithTimeout(0) }, "invalid timeout '0s'")
+}
+
+func TestMergeResults(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	var batches []*Batch
+
+	for i := 0; i < 3; i++ {
+
+		batch := pool.Batch()
+
+		for j := 0; j < 10; j++ {
+			batch.Queue(func() (interface{}, error) {
+				time.Sleep(time.Millisecond)
+				return nil, nil
+			})
+		}
+
+		batch.QueueComplete()
+		batches = append(batches, batch)
+	}
+
+	// cancelling a batch still outputs all of it's Work Units
+	batches[1].Cancel()
+
+	var count int
+
+	for range MergeResults(batches...) {
+		count++
+	}
+
+	Equal(t, count, 30)
+
+	_, ok := <-MergeResults()
+	Equal(t, ok, false)
+}
+
+func TestRunBatch(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	var running, max int32
+
+	fns := make([]WorkFunc, 20)
+
+	for i := range fns {
+		fns[i] = func() (interface{}, error) {
+
+			n := atomic.AddInt32(&running, 1)
+
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond * 5)
+			atomic.AddInt32(&running, -1)
+
+			return nil, nil
+		}
+	}
+
+	results, _ := pool.RunBatch(2, fns)
+
+	var count int
+
+	for wu := range results {
+		Equal(t, wu.Error, nil)
+		count++
+	}
+
+	Equal(t, count, 20)
+	Equal(t, atomic.LoadInt32(&max) <= 2, true)
+
+	results, cancel := pool.RunBatch(0, fns)
+	cancel()
+
+	count = 0
+
+	for range results {
+		count++
+	}
+
+	Equal(t, count, 20)
+}
+
+func TestBatchResultsCtx(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var executed int32
+
+	batch := pool.Batch()
+
+	for i := 0; i < 100; i++ {
+		batch.Queue(func() (interface{}, error) {
+			atomic.AddInt32(&executed, 1)
+			time.Sleep(time.Millisecond)
+			return nil, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count int
+
+	for range batch.ResultsCtx(ctx) {
+		if count++; count == 5 {
+			cancel()
+		}
+	}
+
+	Equal(t, count < 100, true)
+
+	// no more work is run once cancelled
+	for pool.Stats().RunningCount > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, atomic.LoadInt32(&executed) < 100, true)
+
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+
+	count = 0
+
+	for range batch.ResultsCtx(context.Background()) {
+		count++
+	}
+
+	Equal(t, count, 1)
+}
+
+func TestBatchForEach(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+			return i, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	var sum int
+
+	// not called concurrently so needs no locking
+	batch.ForEach(func(wu *WorkUnit) {
+		sum += wu.Value.(int)
+	})
+
+	Equal(t, sum, 45)
+}
+
+func TestBatchQueueChunks(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	items := make([]interface{}, 1050)
+
+	for i := range items {
+		items[i] = i
+	}
+
+	batch := pool.Batch()
+	batch.QueueChunks(items, 100, func(chunk []interface{}) (interface{}, error) {
+
+		var sum int
+
+		for _, item := range chunk {
+			sum += item.(int)
+		}
+
+		return sum, nil
+	})
+	batch.QueueComplete()
+
+	var chunks, sum int
+
+	for wu := range batch.Results() {
+		chunks++
+		sum += wu.Value.(int)
+	}
+
+	Equal(t, chunks, 11)
+	Equal(t, sum, 1049*1050/2)
+
+	PanicMatches(t, func() { pool.Batch().QueueChunks(items, 0, nil) }, "invalid chunkSize '0'")
+}
+
+func TestResetWithOpenBatch(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 4; i++ {
+		batch.Queue(func() (interface{}, error) { return nil, nil })
+	}
+
+	batch.QueueComplete()
+
+	pool.Cancel()
+
+	// the batch's results haven't been read
+	err := pool.Reset()
+
+	_, ok := err.(*ErrBatchesOpen)
+	Equal(t, ok, true)
+	Equal(t, pool.IsClosed(), true)
+
+	for range batch.Results() {
+	}
+
+	// once drained
+	for pool.Reset() != nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, pool.IsClosed(), false)
+
+	// forcibly abandoned
+	batch = pool.Batch()
+
+	for i := 0; i < 4; i++ {
+		batch.Queue(func() (interface{}, error) { return nil, nil })
+	}
+
+	pool.Cancel()
+	pool.ResetForce()
+
+	Equal(t, pool.IsClosed(), false)
+
+	// closes, possibly with some results, rather than blocking forever
+	for range batch.Results() {
+	}
+
+	wu := pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+}
+
+func TestBatchQueueWith(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		i := i
+		batch.QueueWith(i, func() (interface{}, error) {
+			return i * 2, nil
+		})
+	}
+
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+
+	var count int
+
+	for wu := range batch.Results() {
+
+		if wu.Input() == nil {
+			Equal(t, wu.Value, nil)
+			continue
+		}
+
+		count++
+		Equal(t, wu.Value, wu.Input().(int)*2)
+	}
+
+	Equal(t, count, 10)
+}
+
+func TestBatchQueueNilWorkFunc(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	for _, concurrency := range []uint{0, 1} {
+
+		batch := pool.Batch()
+
+		if concurrency > 0 {
+			batch = pool.BatchWithConcurrency(concurrency)
+		}
+
+		batch.Queue(nil)
+		batch.QueueWith("input", nil)
+		batch.QueueAll([]WorkFunc{nil})
+		batch.QueueChunks([]interface{}{1, 2, 3}, 2, nil)
+		batch.Queue(func() (interface{}, error) { return 1, nil })
+		batch.QueueComplete()
+
+		var count, nils int
+
+		for wu := range batch.Results() {
+
+			count++
+
+			if _, ok := wu.Error.(*ErrNilWorkFunc); ok {
+				nils++
+				continue
+			}
+
+			Equal(t, wu.Value, 1)
+		}
+
+		Equal(t, count, 6)
+		Equal(t, nils, 5)
+	}
+}
+
+func TestBatchCancelCause(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	cause := errors.New("upstream is down")
+	block := make(chan struct{})
+
+	batch := pool.Batch()
+
+	batch.Queue(func() (interface{}, error) {
+		<-block
+		return 1, nil
+	})
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(func() (interface{}, error) {
+			return 2, nil
+		})
+	}
+
+	for pool.Stats().RunningCount != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	batch.CancelCause(cause)
+	close(block)
+
+	var count, cancelled int
+
+	for wu := range batch.Results() {
+
+		count++
+
+		if wu.Error != nil {
+			Equal(t, wu.Error, cause)
+			cancelled++
+			continue
+		}
+
+		Equal(t, wu.Value, 1)
+	}
+
+	Equal(t, count, 11)
+	Equal(t, cancelled, 10)
+
+	// a nil cause is a regular Cancel
+	batch = pool.BatchWithConcurrency(1)
+	pool.Pause()
+	batch.Queue(func() (interface{}, error) { return 1, nil })
+	batch.CancelCause(nil)
+	pool.Resume()
+
+	for wu := range batch.Results() {
+		_, ok := wu.Error.(*ErrCancelled)
+		Equal(t, ok, true)
+	}
+}
+
+func TestBatchAbandonPolicy(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 5)
+		return 1, nil
+	}
+
+	batch := pool.Batch()
+	batch.SetAbandonPolicy(AutoCancel, time.Millisecond*20)
+
+	for i := 0; i < 50; i++ {
+		batch.Queue(fn)
+	}
+
+	batch.QueueComplete()
+
+	results := batch.Results()
+
+	// the reader stops after two results
+	for i := 0; i < 2; i++ {
+		wu := <-results
+		Equal(t, wu.Error, nil)
+	}
+
+	batch.m.Lock()
+	units := batch.units
+	batch.m.Unlock()
+
+	WaitAll(units...)
+
+	var cancelled int
+
+	for _, wu := range units {
+		if _, ok := wu.Error.(*ErrCancelled); ok {
+			cancelled++
+		}
+	}
+
+	NotEqual(t, cancelled, 0)
+
+	// closes once the remaining results are discarded
+	for range results {
+	}
+
+	// results are dropped but the work still runs
+	batch = pool.Batch()
+	batch.SetAbandonPolicy(Discard, time.Millisecond*10)
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(fn)
+	}
+
+	batch.QueueComplete()
+	results = batch.Results()
+	<-results
+
+	batch.m.Lock()
+	units = batch.units
+	batch.m.Unlock()
+
+	for _, err := range WaitAll(units...) {
+		Equal(t, err, nil)
+	}
+
+	time.Sleep(time.Millisecond * 30)
+
+	for range results {
+	}
+
+	PanicMatches(t, func() { pool.Batch().SetAbandonPolicy(Discard, 0) }, "invalid abandon after '0s'")
+}
+
+func TestBatchQueueCompleteCancelConcurrently(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	for i := 0; i < 100; i++ {
+
+		batch := pool.Batch()
+
+		for j := 0; j < 10; j++ {
+			batch.Queue(fn)
+		}
+
+		var wg sync.WaitGroup
+
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				if j%2 == 0 {
+					batch.QueueComplete()
+				} else {
+					batch.Cancel()
+				}
+				batch.Queue(fn) // ignored once complete
+			}(j)
+		}
+
+		done := make(chan int)
+
+		go func() {
+			var count int
+			for range batch.Results() {
+				count++
+			}
+			done <- count
+		}()
+
+		wg.Wait()
+
+		select {
+		case count := <-done:
+			Equal(t, count, 10)
+		case <-time.After(time.Second * 5):
+			t.Fatal("deadlocked completing and cancelling concurrently")
+		}
+
+		// still safe once the results have been read
+		batch.QueueComplete()
+		batch.Cancel()
+	}
+
+	// complete after cancel and cancel after complete
+	batch := pool.Batch()
+	batch.Cancel()
+	batch.QueueComplete()
+
+	for range batch.Results() {
+	}
+
+	batch = pool.Batch()
+	batch.QueueComplete()
+	batch.Cancel()
+
+	for range batch.Results() {
+	}
+}
+
+func TestBatchWait(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	errA, errB := errors.New("a"), errors.New("b")
+
+	batch := pool.Batch()
+
+	for i := 0; i < 5; i++ {
+		batch.Queue(func() (interface{}, error) { return 1, nil })
+	}
+
+	batch.Queue(func() (interface{}, error) { return nil, errA })
+	batch.Queue(func() (interface{}, error) { return nil, errB })
+	batch.QueueComplete()
+
+	res := batch.Wait()
+
+	Equal(t, res.Total, 7)
+	Equal(t, res.Succeeded, 5)
+	Equal(t, res.Failed, 2)
+	Equal(t, len(res.Failures), 2)
+	Equal(t, res.Failures[0].Error, errA)
+	Equal(t, res.Failures[1].Error, errB)
+	Equal(t, errors.Is(res.Err(), errA), true)
+	Equal(t, errors.Is(res.Err(), errB), true)
+
+	// safe to call again
+	again := batch.Wait()
+	Equal(t, again.Total, 7)
+	Equal(t, again.Failed, 2)
+
+	// after the results have already been read
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return 1, nil })
+	batch.QueueComplete()
+
+	for range batch.Results() {
+	}
+
+	res = batch.Wait()
+	Equal(t, res.Total, 1)
+	Equal(t, res.Succeeded, 1)
+	Equal(t, res.Err(), nil)
+}
+
+func TestBatchQueueChecked(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	batch := pool.Batch()
+
+	wu, err := batch.QueueChecked(fn)
+	Equal(t, err, nil)
+	Equal(t, wu != nil, true)
+
+	batch.QueueComplete()
+
+	wu, err = batch.QueueChecked(fn)
+	Equal(t, wu == nil, true)
+	_, ok := err.(*ErrBatchComplete)
+	Equal(t, ok, true)
+
+	for range batch.Results() {
+	}
+
+	batch = pool.Batch()
+	batch.Cancel()
+
+	_, err = batch.QueueChecked(fn)
+	_, ok = err.(*ErrBatchCancelled)
+	Equal(t, ok, true)
+
+	for range batch.Results() {
+	}
+
+	// racing producers against QueueComplete, every accepted Work Unit must be output
+	for i := 0; i < 20; i++ {
+
+		batch = pool.Batch()
+
+		var accepted, rejected int32
+		var wg sync.WaitGroup
+
+		for p := 0; p < 4; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 50; j++ {
+
+					if _, err := batch.QueueChecked(fn); err != nil {
+						if _, ok := err.(*ErrBatchComplete); !ok {
+							t.Errorf("unexpected error %v", err)
+						}
+						atomic.AddInt32(&rejected, 1)
+						continue
+					}
+
+					atomic.AddInt32(&accepted, 1)
+				}
+			}()
+		}
+
+		batch.QueueComplete()
+		wg.Wait()
+
+		var n int32
+
+		for range batch.Results() {
+			n++
+		}
+
+		Equal(t, n, atomic.LoadInt32(&accepted))
+		Equal(t, accepted+rejected, int32(200))
+	}
+}
+
+func TestSetErrorClassifier(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	skip, fail := errors.New("skip"), errors.New("fail")
+
+	pool.SetErrorClassifier(func(err error) bool {
+		return !errors.Is(err, skip)
+	})
+
+	batch := pool.Batch()
+	batch.CancelOnError()
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(func() (interface{}, error) { return nil, skip })
+	}
+
+	batch.QueueComplete()
+
+	errs := batch.WaitAndCollect()
+	Equal(t, len(errs), 0)
+	Equal(t, batch.Err(), nil)
+
+	res := batch.Wait()
+	Equal(t, res.Succeeded, 10)
+	Equal(t, res.Failed, 0)
+
+	Equal(t, pool.Stats().ErroredCount, int64(0))
+
+	// real failures still do
+	batch = pool.Batch()
+	batch.CancelOnError()
+	batch.Queue(func() (interface{}, error) { return nil, skip })
+	batch.Queue(func() (interface{}, error) { return nil, fail })
+	batch.QueueComplete()
+
+	errs = batch.WaitAndCollect()
+	Equal(t, len(errs), 1)
+	Equal(t, errs[0], fail)
+	Equal(t, batch.Err(), fail)
+	Equal(t, pool.Stats().ErroredCount, int64(1))
+
+	// the default, every error is a failure
+	pool.SetErrorClassifier(nil)
+
+	wu := pool.Queue(func() (interface{}, error) { return nil, skip })
+	<-wu.Done
+	<-pool.Idle()
+
+	Equal(t, pool.Stats().ErroredCount, int64(2))
+}
+
+func TestBatchSequential(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	var m sync.Mutex
+	var started []int
+	var running, overlapped int32
+
+	batch := pool.Batch()
+	batch.Sequential()
+
+	for i := 0; i < 20; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.StoreInt32(&overlapped, 1)
+			}
+			defer atomic.AddInt32(&running, -1)
+
+			m.Lock()
+			started = append(started, i)
+			m.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			return i, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	var n int
+
+	for range batch.Results() {
+		n++
+	}
+
+	Equal(t, n, 20)
+	Equal(t, atomic.LoadInt32(&overlapped), int32(0))
+
+	for i, s := range started {
+		Equal(t, s, i)
+	}
+
+	// cancelling doesn't leave the rest waiting on their turn
+	release := make(chan struct{})
+
+	batch = pool.Batch()
+	batch.Sequential()
+	batch.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	for i := 0; i < 5; i++ {
+		batch.Queue(func() (interface{}, error) { return 1, nil })
+	}
+
+	batch.Cancel()
+	close(release)
+
+	var cancelled int
+
+	for wu := range batch.Results() {
+		if _, ok := wu.Error.(*ErrCancelled); ok {
+			cancelled++
+		}
+	}
+
+	Equal(t, cancelled >= 5, true)
+}
+
+func TestBatchTakeN(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	fail := errors.New("fail")
+
+	batch := pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, fail })
+	batch.Queue(func() (interface{}, error) { return nil, fail })
+
+	for i := 0; i < 3; i++ {
+		batch.Queue(func() (interface{}, error) { return 1, nil })
+	}
+
+	var slow int32
+
+	for i := 0; i < 20; i++ {
+		batch.Queue(func() (interface{}, error) {
+			atomic.AddInt32(&slow, 1)
+			time.Sleep(time.Millisecond * 100)
+			return 2, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	start := time.Now()
+	taken := batch.TakeN(3)
+
+	Equal(t, len(taken), 3)
+
+	for _, wu := range taken {
+		Equal(t, wu.Value, 1)
+		Equal(t, wu.Error, nil)
+	}
+
+	// the rest were cancelled rather than waited on
+	Equal(t, time.Since(start) < time.Millisecond*400, true)
+	Equal(t, atomic.LoadInt32(&slow) < 20, true)
+
+	select {
+	case <-pool.Idle():
+	case <-time.After(time.Second):
+		t.Fatal("pool should be idle once the rest of the batch is cancelled")
+	}
+
+	// fewer than n succeed
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return 1, nil })
+	batch.Queue(func() (interface{}, error) { return nil, fail })
+	batch.QueueComplete()
+
+	taken = batch.TakeN(2)
+	Equal(t, len(taken), 1)
+	Equal(t, taken[0].Value, 1)
+
+	// errors that aren't failures count towards n
+	nothing := errors.New("nothing to do")
+
+	pool.SetErrorClassifier(func(err error) bool { return err != nothing })
+	defer pool.SetErrorClassifier(nil)
+
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nothing })
+	batch.Queue(func() (interface{}, error) { return nil, fail })
+	batch.QueueComplete()
+
+	taken = batch.TakeN(2)
+	Equal(t, len(taken), 1)
+	Equal(t, taken[0].Error, nothing)
+
+	PanicMatches(t, func() { pool.Batch().TakeN(0) }, "invalid n '0'")
+}
+
+func TestBatchUse(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var m sync.Mutex
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next WorkFunc) WorkFunc {
+			return func() (interface{}, error) {
+				m.Lock()
+				calls = append(calls, name)
+				m.Unlock()
+				return next()
+			}
+		}
+	}
+
+	pool.Use(record("pool"))
+
+	batch := pool.Batch()
+	batch.Use(record("batch1"), record("batch2"))
+	batch.Use(record("batch3"))
+
+	batch.Queue(func() (interface{}, error) {
+		m.Lock()
+		calls = append(calls, "fn")
+		m.Unlock()
+		return nil, nil
+	})
+	batch.QueueComplete()
+	batch.Wait()
+
+	Equal(t, calls, []string{"pool", "batch1", "batch2", "batch3", "fn"})
+
+	// other work on the pool, and other batches, are unaffected
+	calls = nil
+
+	wu := pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	other := pool.Batch()
+	other.Queue(func() (interface{}, error) { return nil, nil })
+	other.QueueComplete()
+	other.Wait()
+
+	Equal(t, calls, []string{"pool", "pool"})
+
+	// only work queued afterwards is wrapped
+	calls = nil
+
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.Use(record("late"))
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+	batch.Wait()
+
+	Equal(t, calls, []string{"pool", "pool", "late"})
+}
+
+func TestBatchSetResultBuffer(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	// returns the # of Work Units run whilst the consumer stalls
+	run := func(buffer int) int32 {
+
+		var ran int32
+
+		batch := pool.Batch()
+		batch.SetResultBuffer(buffer)
+
+		Equal(t, batch.ResultBuffer(), buffer)
+
+		for i := 0; i < 5; i++ {
+			batch.Queue(func() (interface{}, error) {
+				atomic.AddInt32(&ran, 1)
+				return nil, nil
+			})
+		}
+
+		batch.QueueComplete()
+
+		results := batch.Results()
+
+		time.Sleep(time.Millisecond * 100)
+		stalled := atomic.LoadInt32(&ran)
+
+		var count int
+
+		for range results {
+			count++
+		}
+
+		Equal(t, count, 5)
+
+		return stalled
+	}
+
+	// the only worker waits for the first result to be read
+	Equal(t, run(0), int32(1))
+
+	// room for a result more than are run
+	Equal(t, run(2), int32(3))
+	Equal(t, run(5), int32(5))
+
+	// no effect once work has been queued
+	batch := pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.SetResultBuffer(10)
+	Equal(t, batch.ResultBuffer(), 0)
+	batch.QueueComplete()
+	batch.Wait()
+
+	// nor once the results channel has been handed out
+	batch = pool.Batch()
+	results := batch.Results()
+	batch.SetResultBuffer(1)
+	Equal(t, batch.ResultBuffer(), 0)
+
+	batch.Queue(func() (interface{}, error) { return 1, nil })
+	batch.QueueComplete()
+
+	var got []interface{}
+
+	for wu := range results {
+		got = append(got, wu.Value)
+	}
+
+	Equal(t, got, []interface{}{1})
+
+	PanicMatches(t, func() { batch.SetResultBuffer(-1) }, "invalid n '-1'")
+}
+
+func TestBatchMapResults(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+			return strconv.Itoa(i), nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	var calls int32
+	invalid := errors.New("invalid")
+
+	var count, errs, sum int
+
+	for wu := range batch.MapResults(func(wu *WorkUnit) *WorkUnit {
+
+		atomic.AddInt32(&calls, 1)
+
+		n, _ := strconv.Atoi(wu.Value.(string))
+
+		if n%2 == 1 {
+			wu.Error = invalid
+			return wu
+		}
+
+		wu.Value = n
+
+		return wu
+	}) {
+		count++
+
+		if wu.Error != nil {
+			Equal(t, wu.Error, invalid)
+			errs++
+			continue
+		}
+
+		sum += wu.Value.(int)
+	}
+
+	Equal(t, count, 10)
+	Equal(t, errs, 5)
+	Equal(t, sum, 20)
+	Equal(t, atomic.LoadInt32(&calls), int32(10))
+
+	// a panicking mapper
+	batch = pool.Batch()
+	batch.Queue(func() (interface{}, error) { return nil, nil })
+	batch.QueueComplete()
+
+	for wu := range batch.MapResults(func(wu *WorkUnit) *WorkUnit { panic("boom") }) {
+		_, ok := wu.Error.(*PanicError)
+		Equal(t, ok, true)
+	}
+}
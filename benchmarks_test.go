@@ -163,3 +163,301 @@ func BenchmarkBatchSmallRun(b *testing.B) {
 		b.Fatal("Count Incorrect")
 	}
 }
+
+func BenchmarkQueueSinglePriority(b *testing.B) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	res := make([]*WorkUnit, b.N)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res[i] = pool.Queue(fn)
+	}
+
+	for _, cw := range res {
+		<-cw.Done
+	}
+}
+
+func BenchmarkQueueMixedPriority(b *testing.B) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	res := make([]*WorkUnit, b.N)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res[i] = pool.QueueWithPriority(fn, i%10)
+	}
+
+	for _, cw := range res {
+		<-cw.Done
+	}
+}
+
+func benchmarkBuffer(b *testing.B, bufferSize uint) {
+
+	b.ReportAllocs()
+
+	pool := NewWithBuffer(4, bufferSize)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	res := make([]*WorkUnit, b.N)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res[i] = pool.Queue(fn)
+	}
+
+	for _, cw := range res {
+		<-cw.Done
+	}
+}
+
+func BenchmarkBufferNone(b *testing.B) {
+	benchmarkBuffer(b, 0)
+}
+
+func BenchmarkBufferWorkers(b *testing.B) {
+	benchmarkBuffer(b, 4)
+}
+
+func BenchmarkBufferFourTimesWorkers(b *testing.B) {
+	benchmarkBuffer(b, 16)
+}
+
+func benchmarkWaitStrategy(b *testing.B, strategy WaitStrategy) {
+
+	b.ReportAllocs()
+
+	pool := NewWithWaitStrategy(4, strategy)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	res := make([]*WorkUnit, b.N)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res[i] = pool.Queue(fn)
+	}
+
+	for _, cw := range res {
+		<-cw.Done
+	}
+}
+
+func BenchmarkWaitStrategyBlocking(b *testing.B) {
+	benchmarkWaitStrategy(b, Blocking)
+}
+
+func BenchmarkWaitStrategySpinThenBlock(b *testing.B) {
+	benchmarkWaitStrategy(b, SpinThenBlock)
+}
+
+func BenchmarkWaitStrategyYielding(b *testing.B) {
+	benchmarkWaitStrategy(b, Yielding)
+}
+
+func BenchmarkQueueReleaseUnit(b *testing.B) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		wu := pool.Queue(fn)
+		gen := wu.Generation()
+		<-wu.Done
+		pool.ReleaseUnit(wu, gen)
+	}
+}
+
+var benchItems = func() []interface{} {
+
+	items := make([]interface{}, 10000)
+
+	for i := range items {
+		items[i] = i
+	}
+
+	return items
+}()
+
+func sumItems(chunk []interface{}) (interface{}, error) {
+
+	var sum int
+
+	for _, item := range chunk {
+		sum += item.(int)
+	}
+
+	return sum, nil
+}
+
+func BenchmarkBatchUnitPerItem(b *testing.B) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	for n := 0; n < b.N; n++ {
+
+		batch := pool.Batch()
+
+		for i := range benchItems {
+			item := benchItems[i : i+1]
+			batch.Queue(func() (interface{}, error) {
+				return sumItems(item)
+			})
+		}
+
+		batch.QueueComplete()
+
+		for range batch.Results() {
+		}
+	}
+}
+
+func BenchmarkBatchQueueChunks100(b *testing.B) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	for n := 0; n < b.N; n++ {
+
+		batch := pool.Batch()
+		batch.QueueChunks(benchItems, 100, sumItems)
+		batch.QueueComplete()
+
+		for range batch.Results() {
+		}
+	}
+}
+
+func benchmarkQueueProducer(b *testing.B, single bool) {
+
+	b.ReportAllocs()
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.SingleProducer(single)
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	b.ResetTimer()
+
+	// in bursts so the workers keep up rather than the ring filling
+	for i := 0; i < b.N; i++ {
+
+		var wu *WorkUnit
+
+		for j := 0; j < 100; j++ {
+			wu = pool.Queue(fn)
+		}
+
+		<-wu.Done
+	}
+}
+
+func BenchmarkQueueMultiProducer(b *testing.B) {
+	benchmarkQueueProducer(b, false)
+}
+
+func BenchmarkQueueSingleProducer(b *testing.B) {
+	benchmarkQueueProducer(b, true)
+}
+
+// benchmarkSkewed runs a workload where every 4th Work Unit takes far longer than the rest and
+// is given the same key, so pinning the work to the workers by key, as QueueAffinity does, leaves
+// one worker with all of the long ones; unless, as with NewWorkStealing, the idle workers take
+// them off of it.
+func benchmarkSkewed(b *testing.B, pool *Pool, queue func(key uint64, fn WorkFunc) *WorkUnit) {
+
+	defer pool.Close()
+
+	long := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 2)
+		return nil, nil
+	}
+
+	short := func() (interface{}, error) {
+		time.Sleep(time.Microsecond * 100)
+		return nil, nil
+	}
+
+	res := make([]*WorkUnit, 64)
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+
+		for i := range res {
+
+			fn := short
+
+			if i%4 == 0 {
+				fn = long
+			}
+
+			res[i] = queue(uint64(i%4), fn)
+		}
+
+		for _, wu := range res {
+			<-wu.Done
+		}
+	}
+}
+
+func BenchmarkSkewedSharedQueue(b *testing.B) {
+	pool := New(4)
+	benchmarkSkewed(b, pool, func(key uint64, fn WorkFunc) *WorkUnit { return pool.Queue(fn) })
+}
+
+func BenchmarkSkewedAffinity(b *testing.B) {
+	pool := New(4)
+	benchmarkSkewed(b, pool, func(key uint64, fn WorkFunc) *WorkUnit { return pool.QueueAffinity(key, fn) })
+}
+
+func BenchmarkSkewedWorkStealing(b *testing.B) {
+	pool := NewWorkStealing(4)
+	benchmarkSkewed(b, pool, func(key uint64, fn WorkFunc) *WorkUnit { return pool.QueueAffinity(key, fn) })
+}
@@ -0,0 +1,125 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const errCircuitOpen = "ERROR: Work Unit not queued as the circuit breaker for label '%s' is open"
+
+// ErrCircuitOpen is the error set on a labeled Work Unit, see QueueLabeled, that wasn't queued as
+// Work Units with the same label have panicked too many times in a row, see SetPanicCircuitBreaker.
+type ErrCircuitOpen struct {
+	Label string
+	s     string
+}
+
+// Error prints Circuit Open error
+func (e *ErrCircuitOpen) Error() string {
+	return e.s
+}
+
+// breaker tracks the consecutive panics of labeled Work Units, see SetPanicCircuitBreaker.
+type breaker struct {
+	m         sync.Mutex
+	threshold int32 // 0 when disabled
+	handler   func(label string)
+	panics    map[string]int
+	open      map[string]bool
+}
+
+// SetPanicCircuitBreaker stops a poison pill job from churning the workers endlessly; once the
+// Work Units with the same label, see QueueLabeled, have panicked threshold times in a row any
+// more queued with the label are rejected, completing immediately with an ErrCircuitOpen error,
+// until the breaker is reset using ResetCircuit. The handler, if any, is called with the label
+// as the breaker opens. A Work Unit completing without panicking resets the count for it's label
+// and Work Units without a label aren't tracked. Passing a threshold of 0 disables the breaker,
+// resetting all of the labels.
+func (p *Pool) SetPanicCircuitBreaker(threshold int, handler func(label string)) {
+
+	if threshold < 0 {
+		panic(fmt.Sprintf("invalid threshold '%d'", threshold))
+	}
+
+	b := &p.breaker
+
+	b.m.Lock()
+	atomic.StoreInt32(&b.threshold, int32(threshold))
+	b.handler = handler
+	b.panics, b.open = nil, nil
+	b.m.Unlock()
+}
+
+// ResetCircuit closes the circuit breaker for the label, see SetPanicCircuitBreaker, so that
+// Work Units with the label are queued again.
+func (p *Pool) ResetCircuit(label string) {
+
+	b := &p.breaker
+
+	b.m.Lock()
+	delete(b.open, label)
+	delete(b.panics, label)
+	b.m.Unlock()
+}
+
+// circuitOpen reports whether Work Units with the label are being rejected.
+func (p *Pool) circuitOpen(label string) bool {
+
+	b := &p.breaker
+
+	if label == "" || atomic.LoadInt32(&b.threshold) == 0 {
+		return false
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return b.open[label]
+}
+
+// recordPanics counts, or resets the count of, consecutive panics for the Work Unit's label
+// depending on whether it's error is a PanicError, opening the breaker once at the threshold.
+func (p *Pool) recordPanics(wu *WorkUnit, err error) {
+
+	b := &p.breaker
+
+	if wu.label == "" || atomic.LoadInt32(&b.threshold) == 0 {
+		return
+	}
+
+	var pe *PanicError
+
+	b.m.Lock()
+
+	if !errors.As(err, &pe) {
+		delete(b.panics, wu.label)
+		b.m.Unlock()
+		return
+	}
+
+	if b.panics == nil {
+		b.panics = make(map[string]int)
+		b.open = make(map[string]bool)
+	}
+
+	b.panics[wu.label]++
+
+	if b.open[wu.label] || b.panics[wu.label] < int(atomic.LoadInt32(&b.threshold)) {
+		b.m.Unlock()
+		return
+	}
+
+	b.open[wu.label] = true
+	handler := b.handler
+	b.m.Unlock()
+
+	if p.logging() {
+		p.logf("pool: circuit breaker opened for label '%s' after %d consecutive panics", wu.label, atomic.LoadInt32(&b.threshold))
+	}
+
+	if handler != nil {
+		p.guard("circuit breaker handler", func() { handler(wu.label) })
+	}
+}
@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSetPanicCircuitBreaker(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var opened []string
+
+	pool.SetPanicCircuitBreaker(3, func(label string) {
+		opened = append(opened, label)
+	})
+
+	bad := func() (interface{}, error) {
+		panic("poison")
+	}
+
+	good := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	// a success in between resets the count
+	for _, fn := range []WorkFunc{bad, bad, good, bad, bad} {
+		<-pool.QueueLabeled(fn, "job").Done
+	}
+
+	Equal(t, len(opened), 0)
+
+	wu := pool.QueueLabeled(bad, "job")
+	<-wu.Done
+	_, ok := wu.Error.(*PanicError)
+	Equal(t, ok, true)
+	Equal(t, opened, []string{"job"})
+
+	// rejected without being run
+	wu = pool.QueueLabeled(good, "job")
+	<-wu.Done
+
+	cerr, ok := wu.Error.(*ErrCircuitOpen)
+	Equal(t, ok, true)
+	Equal(t, cerr.Label, "job")
+	Equal(t, cerr.Error(), "ERROR: Work Unit not queued as the circuit breaker for label 'job' is open")
+	Equal(t, wu.Value, nil)
+
+	// other labels, and unlabeled work, are unaffected
+	wu = pool.QueueLabeled(good, "other")
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	for i := 0; i < 5; i++ {
+		<-pool.Queue(bad).Done
+	}
+
+	wu = pool.Queue(good)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	pool.ResetCircuit("job")
+
+	wu = pool.QueueLabeled(good, "job")
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	pool.SetPanicCircuitBreaker(0, nil)
+
+	for i := 0; i < 5; i++ {
+		<-pool.QueueLabeled(bad, "job").Done
+	}
+
+	wu = pool.QueueLabeled(good, "job")
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	PanicMatches(t, func() { pool.SetPanicCircuitBreaker(-1, nil) }, "invalid threshold '-1'")
+}
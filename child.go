@@ -0,0 +1,79 @@
+package pool
+
+// NewChild returns a new pool instance whose lifecycle is linked to the parent's, closing or
+// cancelling the parent likewise closes or cancels the child, for example when each of a batch's
+// Work Units queues it's own sub batch on another pool; closing the child has no effect on the
+// parent. Should the parent already be closed the child is returned closed too.
+//
+// NOTE: a child that has been reset re-links to it's parent, but a closed child is unlinked
+// so that the parent no longer holds a reference to it.
+func NewChild(parent *Pool, workers uint) *Pool {
+
+	p := newPool(workers, 0)
+	p.parent = parent
+	parent.adopt(p)
+
+	return p
+}
+
+// adopt links the child so that it's closed along with the pool,
+// closing it immediately if the pool has already been closed.
+func (p *Pool) adopt(child *Pool) {
+
+	p.m.Lock()
+
+	if p.closed {
+
+		var err error = &ErrPoolClosed{s: errClosed}
+
+		if p.cancelled {
+			err = &ErrCancelled{s: errCancelled}
+		}
+
+		p.m.Unlock()
+		child.closeWithError(err)
+
+		return
+	}
+
+	if p.children == nil {
+		p.children = make(map[*Pool]struct{})
+	}
+
+	p.children[child] = struct{}{}
+	p.m.Unlock()
+}
+
+// unlinkChildren returns the pool's children, no longer holding onto them,
+// must be called with the lock held.
+func (p *Pool) unlinkChildren() []*Pool {
+
+	if len(p.children) == 0 {
+		return nil
+	}
+
+	children := make([]*Pool, 0, len(p.children))
+
+	for child := range p.children {
+		children = append(children, child)
+	}
+
+	p.children = nil
+
+	return children
+}
+
+// cascade closes the children with the same error the pool was closed with and unlinks the
+// pool from it's parent; called without the lock held after the pool has been closed.
+func (p *Pool) cascade(children []*Pool, err error) {
+
+	for _, child := range children {
+		child.closeWithError(err)
+	}
+
+	if p.parent != nil {
+		p.parent.m.Lock()
+		delete(p.parent.children, p)
+		p.parent.m.Unlock()
+	}
+}
@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewChild(t *testing.T) {
+
+	parent := New(2)
+	defer parent.Close()
+
+	child := NewChild(parent, 2)
+	defer child.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	for i := 0; i < 2; i++ {
+		child.Queue(func() (interface{}, error) {
+			<-release
+			return nil, nil
+		})
+	}
+
+	queued := child.Queue(func() (interface{}, error) { return nil, nil })
+
+	// cancelling the parent cascades to the child
+	parent.Cancel()
+
+	Equal(t, child.IsCancelled(), true)
+
+	<-queued.Done
+
+	_, ok := queued.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	// closing the child doesn't affect the parent
+	parent.Reset()
+	child.Reset()
+
+	Equal(t, child.IsClosed(), false)
+
+	child.Close()
+
+	Equal(t, parent.IsClosed(), false)
+
+	wu := parent.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+
+	// the closed child is no longer referenced by the parent
+	parent.m.RLock()
+	Equal(t, len(parent.children), 0)
+	parent.m.RUnlock()
+
+	// a reset child re-links to it's parent
+	child.Reset()
+	parent.Close()
+
+	Equal(t, child.IsClosed(), true)
+	Equal(t, child.IsCancelled(), false)
+
+	// a child of a closed parent is closed
+	closed := NewChild(parent, 1)
+
+	Equal(t, closed.IsClosed(), true)
+
+	// grandchildren are closed along with their parent
+	parent.Reset()
+
+	child = NewChild(parent, 1)
+	grandchild := NewChild(child, 1)
+
+	parent.Close()
+
+	Equal(t, grandchild.IsClosed(), true)
+}
@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"time"
+
+	"gopkg.in/go-playground/pool.v2/clock"
+)
+
+// Clock is the source of time used by the pool for all of it's time based behaviour, eg.
+// timeouts, deadlines, retry backoff, rate limits, recurring and slow work, see SetClock and
+// the clock package, which also provides a Fake clock for tests.
+type Clock = clock.Clock
+
+// clockHolder allows storing different Clock implementations, including nil, in the same atomic.Value.
+type clockHolder struct {
+	c Clock
+}
+
+// SetClock sets the clock the pool uses for all of it's time based behaviour, making it
+// deterministically testable with a clock.Fake; passing nil restores the real clock. Timers
+// already started keep using the clock they were started with, eg. NewWithDeadline's deadline
+// until the pool is next Reset(), so set the clock before queuing any work.
+//
+// NOTE: the deadline of the context passed to a context aware WorkFunc is always in real time, that
+// is the time remaining by the pool's clock from now in real time, whereas the Work Unit times out
+// according to the pool's clock.
+func (p *Pool) SetClock(c Clock) {
+	p.clock.Store(clockHolder{c: c})
+}
+
+func (p *Pool) clk() Clock {
+
+	if h, _ := p.clock.Load().(clockHolder); h.c != nil {
+		return h.c
+	}
+
+	return clock.Real
+}
+
+func (p *Pool) now() time.Time {
+	return p.clk().Now()
+}
@@ -0,0 +1,195 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock for tests whose time only moves when Advance is called, firing any timers
+// and tickers that have become due along the way, in the order they're due.
+type Fake struct {
+	m      sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	f      *Fake
+	when   time.Time
+	period time.Duration // non zero for tickers
+	ch     chan time.Time
+	fn     func() // set for timers created by AfterFunc
+}
+
+// NewFake returns a Fake clock starting at now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.m)
+	return f
+}
+
+// Now returns the Fake clock's current time.
+func (f *Fake) Now() time.Time {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the time once the clock has been advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a Timer that fires once the clock has been advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1)}
+	f.schedule(t, d)
+
+	return t
+}
+
+// NewTicker returns a Ticker that fires each time the clock has been advanced by another d,
+// dropping ticks that aren't read in time as time.Ticker does.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1), period: d}
+	f.schedule(t, d)
+
+	return fakeTicker{t}
+}
+
+// AfterFunc returns a Timer that calls f in it's own goroutine once the clock has been advanced by d.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+
+	t := &fakeTimer{f: f, fn: fn}
+	f.schedule(t, d)
+
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers that become due.
+func (f *Fake) Advance(d time.Duration) {
+
+	f.m.Lock()
+	f.now = f.now.Add(d)
+	f.m.Unlock()
+
+	f.fire()
+}
+
+// BlockUntil waits until at least n timers and tickers are waiting to fire, so that a test
+// can be sure the code under test has started it's timer before advancing the clock.
+func (f *Fake) BlockUntil(n int) {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// schedule adds the timer to fire d from now, firing it straight away when d <= 0.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+
+	f.m.Lock()
+	t.when = f.now.Add(d)
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+	f.m.Unlock()
+
+	if d <= 0 {
+		f.fire()
+	}
+}
+
+// fire fires, one at a time, the earliest timer that is due until there are none left that are.
+func (f *Fake) fire() {
+	for {
+
+		f.m.Lock()
+
+		var next *fakeTimer
+
+		for _, t := range f.timers {
+			if !t.when.After(f.now) && (next == nil || t.when.Before(next.when)) {
+				next = t
+			}
+		}
+
+		if next == nil {
+			f.m.Unlock()
+			return
+		}
+
+		when := next.when
+
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			f.remove(next)
+		}
+
+		f.m.Unlock()
+
+		if next.fn != nil {
+			go next.fn()
+			continue
+		}
+
+		select {
+		case next.ch <- when:
+		default:
+		}
+	}
+}
+
+// remove takes the timer off of those waiting to fire, returning whether it was, must be called
+// with the lock held.
+func (f *Fake) remove(t *fakeTimer) bool {
+
+	for i, ft := range f.timers {
+		if ft == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.f.m.Lock()
+	defer t.f.m.Unlock()
+	return t.f.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+
+	t.f.m.Lock()
+	active := t.f.remove(t)
+	t.f.m.Unlock()
+
+	t.f.schedule(t, d)
+
+	return active
+}
+
+// fakeTicker is a fakeTimer with a period, it's Stop returning nothing as time.Ticker's does.
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
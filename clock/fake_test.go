@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestFake(t *testing.T) {
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	Equal(t, f.Now(), start)
+
+	timer := f.NewTimer(time.Second)
+	after := f.After(time.Second * 2)
+	ticker := f.NewTicker(time.Second)
+	called := make(chan struct{})
+	f.AfterFunc(time.Second*3, func() { close(called) })
+
+	select {
+	case <-timer.C():
+		t.Fatal("timer should not fire until the clock is advanced")
+	default:
+	}
+
+	f.Advance(time.Second)
+
+	Equal(t, f.Now(), start.Add(time.Second))
+	Equal(t, <-timer.C(), start.Add(time.Second))
+	Equal(t, <-ticker.C(), start.Add(time.Second))
+	Equal(t, timer.Stop(), false)
+
+	f.Advance(time.Second)
+
+	Equal(t, <-after, start.Add(time.Second*2))
+	Equal(t, <-ticker.C(), start.Add(time.Second*2))
+
+	f.Advance(time.Second)
+	<-called
+
+	// ticks not read in time are dropped
+	f.Advance(time.Second * 5)
+	Equal(t, <-ticker.C(), start.Add(time.Second*3))
+
+	select {
+	case <-ticker.C():
+		t.Fatal("unread ticks should have been dropped")
+	default:
+	}
+
+	ticker.Stop()
+
+	Equal(t, timer.Reset(time.Second), false)
+	Equal(t, timer.Reset(time.Second), true)
+
+	f.Advance(time.Second)
+	Equal(t, <-timer.C(), start.Add(time.Second*9))
+
+	done := make(chan struct{})
+
+	go func() {
+		f.BlockUntil(1)
+		close(done)
+	}()
+
+	f.NewTimer(time.Minute)
+	<-done
+
+	PanicMatches(t, func() { f.NewTicker(0) }, "non-positive interval for NewTicker")
+}
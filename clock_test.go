@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+	"gopkg.in/go-playground/pool.v2/clock"
+)
+
+func TestSetClock(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	pool.SetClock(fake)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	wu := pool.QueueWithTimeout(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}, time.Hour)
+
+	// only times out once the fake clock says so
+	fake.BlockUntil(1)
+
+	select {
+	case <-wu.Done:
+		t.Fatal("Work Unit should not time out until the fake clock is advanced")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	fake.Advance(time.Hour)
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrWorkTimeout)
+	Equal(t, ok, true)
+
+	// the context's deadline is in real time, however far the fake clock is from it
+	for _, now := range []time.Time{start, time.Now().Add(time.Hour * 24 * 365)} {
+
+		fake = clock.NewFake(now)
+		pool.SetClock(fake)
+
+		var deadline time.Time
+		var ctxErr error
+
+		wu = pool.QueueCtxWithTimeout(context.Background(), func(ctx context.Context) (interface{}, error) {
+			deadline, _ = ctx.Deadline()
+			ctxErr = ctx.Err()
+			return nil, nil
+		}, time.Hour)
+
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+		Equal(t, ctxErr, nil)
+		Equal(t, deadline.Sub(time.Now()) > time.Minute*59, true)
+		Equal(t, deadline.Sub(time.Now()) <= time.Hour, true)
+	}
+
+	fake = clock.NewFake(start.Add(time.Hour))
+	pool.SetClock(fake)
+
+	var runs int32
+
+	stop := pool.QueueEvery(func() (interface{}, error) {
+		atomic.AddInt32(&runs, 1)
+		return nil, nil
+	}, time.Minute)
+	defer stop()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+
+	for atomic.LoadInt32(&runs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, atomic.LoadInt32(&runs), int32(1))
+
+	events := pool.Events()
+
+	pool.Queue(func() (interface{}, error) { return nil, nil })
+	Equal(t, (<-events).Time, start.Add(time.Hour+time.Minute))
+
+	pool.SetClock(nil)
+	Equal(t, pool.now().After(start.Add(time.Hour*24)), true)
+}
@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// costs keeps track of the total cost of the Work Units in flight against the limit.
+type costs struct {
+	m     sync.Mutex
+	freed *sync.Cond
+	limit int64
+	used  int64
+}
+
+// reserve waits until there is enough budget for the cost, reserving it.
+func (c *costs) reserve(cost int64) {
+
+	c.m.Lock()
+
+	for c.used+cost > c.limit {
+		c.freed.Wait()
+	}
+
+	c.used += cost
+	c.m.Unlock()
+}
+
+func (c *costs) release(cost int64) {
+	c.m.Lock()
+	c.used -= cost
+	c.freed.Broadcast()
+	c.m.Unlock()
+}
+
+// NewWithCostLimit returns a new pool instance that caps the total cost, in whatever unit makes
+// sense such as bytes of buffers, of the Work Units queued or running, see QueueWithCost; eg. so
+// there is never more than 2GB of buffers in flight.
+func NewWithCostLimit(workers uint, maxCost int64) *Pool {
+
+	if maxCost <= 0 {
+		panic(fmt.Sprintf("invalid maxCost '%d'", maxCost))
+	}
+
+	p := newPool(workers, 0)
+
+	c := &costs{limit: maxCost}
+	c.freed = sync.NewCond(&c.m)
+
+	p.m.Lock()
+	p.costs = c
+	p.m.Unlock()
+
+	return p
+}
+
+// QueueWithCost queues the work to be run, and starts processing immediately, once there is
+// enough of the pool's cost budget, see NewWithCostLimit, blocking until there is. The cost is
+// reserved until the Work Unit is Done, however that may be including being cancelled, and an
+// ErrCostTooHigh error returned, without queuing the work, if the cost exceeds the pool's limit,
+// as is an ErrNilWorkFunc error if fn is nil. On pools without a cost limit the cost is ignored.
+func (p *Pool) QueueWithCost(fn WorkFunc, cost int64) (*WorkUnit, error) {
+
+	if cost < 0 {
+		panic(fmt.Sprintf("invalid cost '%d'", cost))
+	}
+
+	if fn == nil {
+		return nil, &ErrNilWorkFunc{s: errNilFunc}
+	}
+
+	p.m.RLock()
+	c := p.costs
+	p.m.RUnlock()
+
+	wu := &WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+		pool: p,
+	}
+
+	if c == nil || cost == 0 {
+		return p.enqueue(wu), nil
+	}
+
+	if cost > c.limit {
+		return nil, &ErrCostTooHigh{s: errCost}
+	}
+
+	c.reserve(cost)
+	wu.cost = cost
+
+	return p.enqueue(wu), nil
+}
@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewWithCostLimit(t *testing.T) {
+
+	pool := NewWithCostLimit(4, 100)
+	defer pool.Close()
+
+	var inFlight, max int64
+
+	fn := func(cost int64) WorkFunc {
+		return func() (interface{}, error) {
+
+			n := atomic.AddInt64(&inFlight, cost)
+
+			for {
+				m := atomic.LoadInt64(&max)
+				if n <= m || atomic.CompareAndSwapInt64(&max, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond * 5)
+			atomic.AddInt64(&inFlight, -cost)
+
+			return nil, nil
+		}
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 20; i++ {
+		wu, err := pool.QueueWithCost(fn(40), 40)
+		Equal(t, err, nil)
+		res = append(res, wu)
+	}
+
+	WaitAll(res...)
+
+	Equal(t, atomic.LoadInt64(&max) <= 100, true)
+
+	wu, err := pool.QueueWithCost(fn(101), 101)
+	Equal(t, wu == nil, true)
+
+	_, ok := err.(*ErrCostTooHigh)
+	Equal(t, ok, true)
+
+	// cancelled Work Units release their cost
+	block := make(chan struct{})
+
+	running, _ := pool.QueueWithCost(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	}, 50)
+
+	pool.Pause()
+
+	queued, _ := pool.QueueWithCost(fn(50), 50)
+	queued.Cancel()
+
+	wu, err = pool.QueueWithCost(fn(50), 50)
+	Equal(t, err, nil)
+
+	pool.Resume()
+	close(block)
+	WaitAll(running, wu)
+
+	pool.costs.m.Lock()
+	Equal(t, pool.costs.used, int64(0))
+	pool.costs.m.Unlock()
+
+	PanicMatches(t, func() { pool.QueueWithCost(nil, -1) }, "invalid cost '-1'")
+	PanicMatches(t, func() { NewWithCostLimit(1, 0) }, "invalid maxCost '0'")
+
+	// the cost is ignored without a limit
+	p2 := New(1)
+	defer p2.Close()
+
+	wu, err = p2.QueueWithCost(fn(1000), 1000)
+	Equal(t, err, nil)
+	<-wu.Done
+}
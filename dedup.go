@@ -0,0 +1,43 @@
+package pool
+
+// QueueDedup queues the work to be run, and starts processing immediately, unless a Work Unit
+// queued with the same key is still queued or running in which case that Work Unit is returned
+// instead, sharing it's result, so that the WorkFunc runs only once; eg. when filling a cache
+// from multiple goroutines. The key is forgotten once the Work Unit completes.
+func (p *Pool) QueueDedup(key string, fn WorkFunc) *WorkUnit {
+
+	p.dm.Lock()
+
+	if wu, ok := p.dedup[key]; ok && !wu.IsDone() {
+		p.dm.Unlock()
+		return wu
+	}
+
+	if p.dedup == nil {
+		p.dedup = make(map[string]*WorkUnit)
+	}
+
+	wu := &WorkUnit{
+		Done:   make(chan struct{}),
+		fn:     fn,
+		shared: true,
+	}
+
+	p.dedup[key] = wu
+	p.dm.Unlock()
+
+	p.enqueue(wu)
+
+	go func() {
+
+		<-wu.Done
+
+		p.dm.Lock()
+		if p.dedup[key] == wu {
+			delete(p.dedup, key)
+		}
+		p.dm.Unlock()
+	}()
+
+	return wu
+}
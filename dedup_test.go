@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueDedup(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	var runs int32
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	units := make([]*WorkUnit, 10)
+
+	for i := range units {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			units[i] = pool.QueueDedup("key", fn)
+		}(i)
+	}
+
+	wg.Wait()
+
+	// a different key runs separately
+	other := pool.QueueDedup("other", func() (interface{}, error) { return "other", nil })
+
+	close(release)
+
+	for _, wu := range units {
+		<-wu.Done
+		Equal(t, wu.Value, "value")
+	}
+
+	<-other.Done
+
+	Equal(t, other.Value, "other")
+	Equal(t, atomic.LoadInt32(&runs), int32(1))
+
+	// the key is forgotten once complete
+	for {
+		pool.dm.Lock()
+		n := len(pool.dedup)
+		pool.dm.Unlock()
+
+		if n == 0 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	wu := pool.QueueDedup("key", fn)
+	<-wu.Done
+
+	Equal(t, atomic.LoadInt32(&runs), int32(2))
+}
@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"runtime"
+	"sync"
+)
+
+var (
+	defaultOnce sync.Once
+	defaultM    sync.Mutex
+	defaultSize uint
+	defaultPool *Pool
+)
+
+// SetDefaultSize sets the # of workers of the default pool used by Go(), which otherwise
+// defaults to GOMAXPROCS; it has no effect once the default pool has been used.
+func SetDefaultSize(workers uint) {
+
+	if workers == 0 {
+		panic("invalid workers '0'")
+	}
+
+	defaultM.Lock()
+	defaultSize = workers
+	defaultM.Unlock()
+}
+
+// Go queues the work to be run on the default pool, which is created on first use, and starts
+// processing immediately; for quick scripts that don't want to manage a pool of their own.
+//
+// NOTE: the default pool is never closed.
+func Go(fn WorkFunc) *WorkUnit {
+
+	defaultOnce.Do(func() {
+
+		defaultM.Lock()
+		workers := defaultSize
+		defaultM.Unlock()
+
+		if workers == 0 {
+			workers = uint(runtime.GOMAXPROCS(0))
+		}
+
+		defaultPool = New(workers)
+	})
+
+	return defaultPool.Queue(fn)
+}
@@ -0,0 +1,29 @@
+package pool
+
+import (
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestGo(t *testing.T) {
+
+	PanicMatches(t, func() { SetDefaultSize(0) }, "invalid workers '0'")
+
+	SetDefaultSize(3)
+
+	wu := Go(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+	Equal(t, defaultPool.workers, uint(3))
+
+	// no effect once used
+	SetDefaultSize(5)
+
+	wu = Go(func() (interface{}, error) { return 2, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 2)
+	Equal(t, defaultPool.workers, uint(3))
+}
@@ -0,0 +1,115 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// eventBuffer is the # of Events buffered before they start getting dropped, see Events.
+const eventBuffer = 1024
+
+// EventType is the type of an Event.
+type EventType uint8
+
+// Event types
+const (
+	EventQueued        EventType = iota // a Work Unit was accepted onto the queue
+	EventStarted                        // a Work Unit began executing
+	EventCompleted                      // a Work Unit finished without error
+	EventErrored                        // a Work Unit finished with an error
+	EventCancelled                      // a Work Unit was cancelled before it started
+	EventWorkerStarted                  // a worker was started
+	EventWorkerStopped                  // a worker exited
+)
+
+var eventNames = [...]string{
+	EventQueued:        "Queued",
+	EventStarted:       "Started",
+	EventCompleted:     "Completed",
+	EventErrored:       "Errored",
+	EventCancelled:     "Cancelled",
+	EventWorkerStarted: "WorkerStarted",
+	EventWorkerStopped: "WorkerStopped",
+}
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+
+	if int(t) < len(eventNames) {
+		return eventNames[t]
+	}
+
+	return "Unknown"
+}
+
+// Event is a single pool lifecycle event, see Events.
+type Event struct {
+	Type     EventType
+	Time     time.Time
+	UnitID   uint64 // ID of the Work Unit, 0 for worker events
+	WorkerID int    // ID of the worker for worker and Started events, otherwise 0
+	Err      error  // the Work Unit's error for Errored and Cancelled events
+}
+
+// eventStream is the channel Events are sent to along with the # dropped.
+type eventStream struct {
+	ch      chan Event
+	dropped uint64
+}
+
+// Events enables, on first use, and returns a buffered channel of the pool's lifecycle events,
+// a firehose for reconstructing the timeline of a misbehaving batch whilst debugging. Events are
+// sent without ever blocking the pool, should the buffer be full they're dropped and counted, see
+// DroppedEvents, so the channel should be read promptly. The same channel is returned each call
+// and is never closed. Until enabled there is no overhead.
+func (p *Pool) Events() <-chan Event {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if s, ok := p.events.Load().(*eventStream); ok {
+		return s.ch
+	}
+
+	s := &eventStream{ch: make(chan Event, eventBuffer)}
+	p.events.Store(s)
+
+	return s.ch
+}
+
+// DroppedEvents returns the # of events dropped as the Events() channel's buffer was full.
+func (p *Pool) DroppedEvents() uint64 {
+
+	if s, ok := p.events.Load().(*eventStream); ok {
+		return atomic.LoadUint64(&s.dropped)
+	}
+
+	return 0
+}
+
+// emit sends the event, if enabled, without blocking.
+func (p *Pool) emit(t EventType, unitID uint64, workerID int, err error) {
+
+	s, ok := p.events.Load().(*eventStream)
+
+	if !ok {
+		return
+	}
+
+	select {
+	case s.ch <- Event{Type: t, Time: p.now(), UnitID: unitID, WorkerID: workerID, Err: err}:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// emitDone sends the Completed or Errored event for the Work Unit.
+func (p *Pool) emitDone(wu *WorkUnit, err error) {
+
+	if err != nil {
+		p.emit(EventErrored, wu.id, 0, err)
+		return
+	}
+
+	p.emit(EventCompleted, wu.id, 0, nil)
+}
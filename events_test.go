@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestEvents(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	events := pool.Events()
+	Equal(t, pool.Events(), events)
+
+	errFailed := errors.New("failed")
+
+	ok := pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-ok.Done
+
+	failed := pool.Queue(func() (interface{}, error) { return nil, errFailed })
+	<-failed.Done
+
+	pool.Pause()
+	cancelled := pool.Queue(func() (interface{}, error) { return 1, nil })
+	cancelled.Cancel()
+	pool.Resume()
+
+	pool.Resize(2)
+	pool.Resize(1)
+
+	var got []Event
+
+	for len(got) < 10 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("only received %d events", len(got))
+		}
+	}
+
+	types := func(id uint64) []EventType {
+
+		var types []EventType
+
+		for _, e := range got {
+			if e.UnitID == id && e.UnitID != 0 {
+				types = append(types, e.Type)
+			}
+		}
+
+		return types
+	}
+
+	Equal(t, types(ok.ID()), []EventType{EventQueued, EventStarted, EventCompleted})
+	Equal(t, types(failed.ID()), []EventType{EventQueued, EventStarted, EventErrored})
+	Equal(t, types(cancelled.ID()), []EventType{EventQueued, EventCancelled})
+
+	var started, stopped int
+
+	for _, e := range got {
+
+		Equal(t, e.Time.IsZero(), false)
+
+		switch e.Type {
+		case EventErrored:
+			Equal(t, e.Err, errFailed)
+		case EventStarted:
+			NotEqual(t, e.WorkerID, 0)
+		case EventWorkerStarted:
+			started++
+			Equal(t, e.WorkerID, 2)
+		case EventWorkerStopped:
+			stopped++
+			Equal(t, e.WorkerID, 2)
+		}
+	}
+
+	Equal(t, started, 1)
+	Equal(t, stopped, 1)
+	Equal(t, EventWorkerStopped.String(), "WorkerStopped")
+	Equal(t, EventType(100).String(), "Unknown")
+	Equal(t, pool.DroppedEvents(), uint64(0))
+}
+
+func TestEventsDropped(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	Equal(t, pool.DroppedEvents(), uint64(0))
+
+	// never read
+	pool.Events()
+
+	var res []*WorkUnit
+
+	for i := 0; i < eventBuffer; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) { return nil, nil }))
+	}
+
+	WaitAll(res...)
+
+	NotEqual(t, pool.DroppedEvents(), uint64(0))
+}
@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueueEvery queues the work to be run on the pool repeatedly, every interval, until the
+// returned stop function is called; the first run happens one interval after calling.
+// If the previous run has not finished when the interval elapses again that tick is skipped
+// rather than stacking up runs. Any errors, including ErrPoolClosed should the pool be closed,
+// are passed to the optional onError callbacks which are called from the scheduling goroutine,
+// a panicking callback is recovered and reported like any other, see SetPanicHandler.
+//
+// NOTE: the schedule keeps running until stop is called, even if the pool is closed.
+func (p *Pool) QueueEvery(fn WorkFunc, interval time.Duration, onError ...func(err error)) (stop func()) {
+
+	if interval <= 0 {
+		panic(fmt.Sprintf("invalid interval '%s'", interval))
+	}
+
+	quit := make(chan struct{})
+	once := new(sync.Once)
+
+	go func(p *Pool) {
+
+		t := p.clk().NewTicker(interval)
+		defer t.Stop()
+
+		var wu *WorkUnit
+		var done <-chan struct{} // nil whilst there is no run in progress
+
+		for {
+			select {
+			case <-quit:
+				return
+
+			case <-done:
+				done = nil
+
+				if wu.Error != nil {
+					for _, cb := range onError {
+						p.guard("QueueEvery onError callback", func() { cb(wu.Error) })
+					}
+				}
+
+			case <-t.C():
+				// previous run still in progress, skip this tick
+				if done != nil {
+					continue
+				}
+
+				wu = p.enqueue(&WorkUnit{Done: make(chan struct{}), fn: fn})
+				done = wu.Done
+			}
+		}
+	}(p)
+
+	return func() {
+		once.Do(func() {
+			close(quit)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueEvery(t *testing.T) {
+
+	var runs int32
+
+	pool := New(2)
+	defer pool.Close()
+
+	stop := pool.QueueEvery(func() (interface{}, error) {
+		atomic.AddInt32(&runs, 1)
+		return nil, nil
+	}, time.Millisecond*20)
+
+	time.Sleep(time.Millisecond * 110)
+	stop()
+	stop() // safe to call more than once
+
+	n := atomic.LoadInt32(&runs)
+	Equal(t, n >= 3 && n <= 6, true)
+
+	time.Sleep(time.Millisecond * 60)
+	Equal(t, atomic.LoadInt32(&runs), n)
+
+	PanicMatches(t, func() { pool.QueueEvery(nil, 0) }, "invalid interval '0s'")
+}
+
+func TestQueueEverySkipsAndErrors(t *testing.T) {
+
+	var running, max, runs int32
+
+	pool := New(4)
+	defer pool.Close()
+
+	bad := errors.New("bad")
+
+	var m sync.Mutex
+	var errs []error
+
+	stop := pool.QueueEvery(func() (interface{}, error) {
+
+		if n := atomic.AddInt32(&running, 1); n > atomic.LoadInt32(&max) {
+			atomic.StoreInt32(&max, n)
+		}
+
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(time.Millisecond * 50)
+		atomic.AddInt32(&running, -1)
+
+		return nil, bad
+	}, time.Millisecond*10, func(err error) {
+		panic("onError")
+	}, func(err error) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+
+	time.Sleep(time.Millisecond * 200)
+	stop()
+
+	// runs never overlap and ticks during a run are skipped
+	Equal(t, atomic.LoadInt32(&max), int32(1))
+	Equal(t, atomic.LoadInt32(&runs) <= 4, true)
+
+	m.Lock()
+	Equal(t, len(errs) > 0, true)
+	Equal(t, errs[0], bad)
+	m.Unlock()
+}
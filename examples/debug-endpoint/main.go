@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"gopkg.in/go-playground/pool.v2"
+)
+
+func main() {
+
+	p := pool.New(10)
+	defer p.Close()
+
+	// curl localhost:8080/debug/pool
+	http.HandleFunc("/debug/pool", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(p.Snapshot()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	for i := 0; i < 100; i++ {
+		p.QueueLabeled(work, "example")
+	}
+
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+
+func work() (interface{}, error) {
+	time.Sleep(time.Second * 5)
+	return nil, nil
+}
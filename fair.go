@@ -0,0 +1,81 @@
+package pool
+
+import "fmt"
+
+// fairScale is the virtual time a Work Unit of a weight 1 flow takes up, see NewFair.
+const fairScale = 1 << 20
+
+// flow is a stream of Work Units, those of a batch or those queued on the pool directly,
+// dispatched fairly with the others, see NewFair; guarded by the pool's lock.
+type flow struct {
+	weight uint64
+	finish uint64 // the tag of the last Work Unit queued
+}
+
+// NewFair returns a new pool instance that dispatches fairly between batches, rather than strictly
+// in the order queued, so that a large batch flooding the queue doesn't starve a small batch queued
+// after it; the workers take turns between the batches with work queued, round robin, or in
+// proportion to their weights, see Batch.SetWeight. Work queued on the pool directly, not on a
+// batch, is treated as one more batch. Priorities still take precedence.
+//
+// NOTE: only the order in which queued Work Units are started is affected, a batch whose Work
+// Units run longer still takes up more of the workers' time.
+func NewFair(workers uint) *Pool {
+
+	p := newPool(workers, 0)
+
+	p.m.Lock()
+	p.fair = true
+	p.m.Unlock()
+
+	return p
+}
+
+// SetWeight sets the batch's share of the pool's workers relative to the other batches, when the
+// pool dispatches fairly, see NewFair; eg. a batch with a weight of 3 has 3 of it's Work Units
+// started for every one of a batch with the default weight of 1. It must be called before any
+// work is queued as work already queued isn't affected.
+func (b *Batch) SetWeight(weight uint) {
+
+	if weight == 0 {
+		panic(fmt.Sprintf("invalid weight '%d'", weight))
+	}
+
+	b.pool.m.Lock()
+	b.flow.weight = uint64(weight)
+	b.pool.m.Unlock()
+}
+
+// sequence stamps the Work Unit with the order it's to be dequeued in, relative to those of the
+// same priority, before it's pushed on to the heap; must be called with the lock held.
+func (p *Pool) sequence(wu *WorkUnit) {
+
+	p.seq++
+	wu.seq = p.seq
+
+	if !p.fair {
+		return
+	}
+
+	f := wu.flow
+
+	if f == nil {
+		f = &p.unbatched
+	}
+
+	weight := f.weight
+
+	if weight == 0 {
+		weight = 1
+	}
+
+	// a flow that has been idle starts again from now rather than jumping the queue
+	start := f.finish
+
+	if start < p.vtime {
+		start = p.vtime
+	}
+
+	f.finish = start + fairScale/weight
+	wu.tag = f.finish
+}
@@ -0,0 +1,133 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewFair(t *testing.T) {
+
+	pool := NewFair(1)
+	defer pool.Close()
+
+	release := make(chan struct{})
+	blocker := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	var m sync.Mutex
+	var order []string
+
+	record := func(name string) WorkFunc {
+		return func() (interface{}, error) {
+			m.Lock()
+			order = append(order, name)
+			m.Unlock()
+			return nil, nil
+		}
+	}
+
+	large := pool.Batch()
+
+	for i := 0; i < 1000; i++ {
+		large.Queue(record("large"))
+	}
+
+	large.QueueComplete()
+
+	small := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		small.Queue(record("small"))
+	}
+
+	small.QueueComplete()
+
+	// all queued whilst the only worker is busy
+	for pool.Pending() < 1010 {
+	}
+
+	close(release)
+	<-blocker.Done
+
+	small.Wait()
+	large.Wait()
+
+	m.Lock()
+	defer m.Unlock()
+
+	var last int
+
+	for i, name := range order {
+		if name == "small" {
+			last = i
+		}
+	}
+
+	// taking turns the small batch is done within the first 25 rather than stuck behind the large
+	Equal(t, len(order), 1010)
+	Equal(t, last < 25, true)
+}
+
+func TestBatchSetWeight(t *testing.T) {
+
+	pool := NewFair(1)
+	defer pool.Close()
+
+	release := make(chan struct{})
+	blocker := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	var m sync.Mutex
+	var order []string
+
+	record := func(name string) WorkFunc {
+		return func() (interface{}, error) {
+			m.Lock()
+			order = append(order, name)
+			m.Unlock()
+			return nil, nil
+		}
+	}
+
+	light := pool.Batch()
+	heavy := pool.Batch()
+	heavy.SetWeight(3)
+
+	for i := 0; i < 100; i++ {
+		light.Queue(record("light"))
+		heavy.Queue(record("heavy"))
+	}
+
+	light.QueueComplete()
+	heavy.QueueComplete()
+
+	for pool.Pending() < 200 {
+	}
+
+	close(release)
+	<-blocker.Done
+
+	light.Wait()
+	heavy.Wait()
+
+	m.Lock()
+	defer m.Unlock()
+
+	var heavies int
+
+	for _, name := range order[:40] {
+		if name == "heavy" {
+			heavies++
+		}
+	}
+
+	Equal(t, heavies, 30)
+
+	PanicMatches(t, func() { heavy.SetWeight(0) }, "invalid weight '0'")
+}
@@ -0,0 +1,76 @@
+package pool
+
+import "time"
+
+// Future is the pending result of work submitted to the pool, see Submit, for synchronous
+// call sites that just want the result.
+type Future struct {
+	wu *WorkUnit
+}
+
+// Submit queues the work to be run, and starts processing immediately, returning a
+// Future for it's result.
+func (p *Pool) Submit(fn WorkFunc) *Future {
+	wu := p.Queue(fn)
+	wu.shared = true
+
+	return &Future{wu: wu}
+}
+
+// Get blocks until the work has completed returning it's value and error,
+// calling it again returns the same results.
+func (f *Future) Get() (interface{}, error) {
+	<-f.wu.Done
+	return f.wu.Value, f.wu.Error
+}
+
+// GetWithTimeout is the same as Get() but returns an ErrWorkTimeout error should the work not
+// complete within d; the work is not cancelled and Get can be called again later.
+func (f *Future) GetWithTimeout(d time.Duration) (interface{}, error) {
+
+	if !f.wu.Wait(d) {
+		return nil, &ErrWorkTimeout{s: errTimeout}
+	}
+
+	return f.wu.Value, f.wu.Error
+}
+
+// Unit returns the Work Unit behind the Future, eg. for cancelling it.
+func (f *Future) Unit() *WorkUnit {
+	return f.wu
+}
+
+// TypedFuture is the pending result of work submitted to a TypedPool.
+type TypedFuture[T any] struct {
+	tu *TypedWorkUnit[T]
+}
+
+// Submit queues the work to be run, and starts processing immediately, returning a
+// TypedFuture for it's result.
+func (p *TypedPool[T]) Submit(fn TypedWorkFunc[T]) *TypedFuture[T] {
+	return &TypedFuture[T]{tu: p.Queue(fn)}
+}
+
+// Get blocks until the work has completed returning it's value and error,
+// calling it again returns the same results.
+func (f *TypedFuture[T]) Get() (T, error) {
+	<-f.tu.Done
+	return f.tu.Value, f.tu.Error
+}
+
+// GetWithTimeout is the same as Get() but returns an ErrWorkTimeout error should the work not
+// complete within d; the work is not cancelled and Get can be called again later.
+func (f *TypedFuture[T]) GetWithTimeout(d time.Duration) (T, error) {
+
+	if !f.tu.Wait(d) {
+		var zero T
+		return zero, &ErrWorkTimeout{s: errTimeout}
+	}
+
+	return f.tu.Value, f.tu.Error
+}
+
+// Unit returns the Work Unit behind the TypedFuture, eg. for cancelling it.
+func (f *TypedFuture[T]) Unit() *TypedWorkUnit[T] {
+	return f.tu
+}
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSubmit(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	f := pool.Submit(func() (interface{}, error) { return 1, nil })
+
+	v, err := f.Get()
+	Equal(t, err, nil)
+	Equal(t, v, 1)
+
+	// cached
+	v, err = f.Get()
+	Equal(t, err, nil)
+	Equal(t, v, 1)
+
+	release := make(chan struct{})
+
+	f = pool.Submit(func() (interface{}, error) {
+		<-release
+		return nil, errors.New("failed")
+	})
+
+	v, err = f.GetWithTimeout(time.Millisecond * 10)
+	Equal(t, v, nil)
+
+	_, ok := err.(*ErrWorkTimeout)
+	Equal(t, ok, true)
+
+	close(release)
+
+	v, err = f.GetWithTimeout(time.Second)
+	Equal(t, v, nil)
+	Equal(t, err.Error(), "failed")
+	Equal(t, f.Unit().IsDone(), true)
+}
+
+func TestTypedSubmit(t *testing.T) {
+
+	pool := NewTyped[string](2)
+	defer pool.Close()
+
+	f := pool.Submit(func() (string, error) { return "done", nil })
+
+	v, err := f.Get()
+	Equal(t, err, nil)
+	Equal(t, v, "done")
+
+	release := make(chan struct{})
+
+	f = pool.Submit(func() (string, error) {
+		<-release
+		return "later", nil
+	})
+
+	v, err = f.GetWithTimeout(time.Millisecond * 10)
+	Equal(t, v, "")
+
+	_, ok := err.(*ErrWorkTimeout)
+	Equal(t, ok, true)
+
+	close(release)
+
+	v, err = f.Get()
+	Equal(t, err, nil)
+	Equal(t, v, "later")
+}
@@ -0,0 +1,31 @@
+package pool
+
+import "runtime/debug"
+
+// guard calls fn, which calls a user supplied callback, recovering should the callback panic so
+// that it can't take down, or leave in a bad way, the goroutine calling it, which may be one of
+// the pool's own; the panic is logged, see SetLogger, and passed to the panic handler instead.
+func (p *Pool) guard(callback string, fn func()) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.callbackPanicked(callback, r)
+		}
+	}()
+
+	fn()
+}
+
+// callbackPanicked reports the value recovered from a panicking callback, see guard.
+func (p *Pool) callbackPanicked(callback string, recovered interface{}) {
+
+	stack := debug.Stack()
+
+	if p.logging() {
+		p.logf("pool: %s panicked: '%v'\n%s", callback, recovered, stack)
+	}
+
+	if h, ok := p.panicHandler.Load().(func(interface{}, []byte)); ok && h != nil {
+		p.callPanicHandler(h, recovered, stack)
+	}
+}
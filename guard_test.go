@@ -0,0 +1,151 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+type panickingObserver struct{}
+
+func (panickingObserver) OnQueue()                        { panic("bad OnQueue") }
+func (panickingObserver) OnStart()                        { panic("bad OnStart") }
+func (panickingObserver) OnComplete(time.Duration, error) { panic("bad OnComplete") }
+
+// panics recorded by the panic handler
+type panics struct {
+	m    sync.Mutex
+	seen map[interface{}]bool
+}
+
+func (p *panics) handler(r interface{}, _ []byte) {
+	p.m.Lock()
+	p.seen[r] = true
+	p.m.Unlock()
+}
+
+func (p *panics) saw(r interface{}) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.seen[r]
+}
+
+func TestGuardedCallbacks(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	l := new(testLogger)
+	pool.SetLogger(l)
+
+	seen := &panics{seen: make(map[interface{}]bool)}
+	pool.SetPanicHandler(seen.handler)
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	pool.SetMetricsObserver(panickingObserver{})
+
+	wu := pool.Queue(fn)
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Error, nil)
+	Equal(t, seen.saw("bad OnQueue"), true)
+	Equal(t, seen.saw("bad OnStart"), true)
+
+	// OnComplete is called just before the Work Unit is marked Done
+	<-pool.Idle()
+	Equal(t, seen.saw("bad OnComplete"), true)
+	Equal(t, l.logged("MetricsObserver panicked: 'bad OnQueue'"), true)
+
+	pool.SetMetricsObserver(nil)
+
+	pool.SetTracer(func(ctx context.Context, unit *WorkUnit) (context.Context, func(err error)) {
+		return ctx, func(err error) { panic("bad end") }
+	})
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	pool.SetTracer(func(ctx context.Context, unit *WorkUnit) (context.Context, func(err error)) {
+		panic("bad tracer")
+	})
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	<-pool.Idle()
+	Equal(t, seen.saw("bad end"), true)
+	Equal(t, seen.saw("bad tracer"), true)
+
+	pool.SetTracer(nil)
+
+	slow := make(chan struct{})
+
+	pool.SetSlowThreshold(time.Millisecond*10, func(unit *WorkUnit, elapsed time.Duration) {
+		defer close(slow)
+		panic("bad slow")
+	})
+
+	wu = pool.Queue(func() (interface{}, error) {
+		<-slow
+		return 1, nil
+	})
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+	Equal(t, seen.saw("bad slow"), true)
+
+	pool.SetSlowThreshold(0, nil)
+
+	// the pool's workers are all still running
+	Equal(t, pool.WorkerCount(), 2)
+
+	batch := pool.Batch()
+	batch.OnProgress(func(completed, total int) {
+		panic("bad progress")
+	})
+
+	for i := 0; i < 10; i++ {
+		batch.Queue(fn)
+	}
+
+	batch.QueueComplete()
+
+	var n int
+
+	batch.ForEach(func(wu *WorkUnit) {
+		n++
+		panic("bad ForEach")
+	})
+
+	Equal(t, n, 10)
+	Equal(t, seen.saw("bad progress"), true)
+	Equal(t, seen.saw("bad ForEach"), true)
+
+	// and hooks on a pool of it's own, as they're only called on start and stop
+	hooked := New(2)
+
+	hooked.SetPanicHandler(seen.handler)
+	hooked.OnWorkerStart(func(workerID int) { panic("bad start") })
+	hooked.OnWorkerStop(func(workerID int) { panic("bad stop") })
+
+	wu = hooked.Queue(fn)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+	Equal(t, seen.saw("bad start"), true)
+
+	hooked.Close()
+
+	for hooked.WorkerCount() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, seen.saw("bad stop"), true)
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is the # of most recent execution durations kept for LatencyStats.
+const latencySamples = 1024
+
+// LatencyStats contains the distribution of the execution durations of the pool's most
+// recently completed Work Units, see Pool.LatencyStats().
+type LatencyStats struct {
+	Count int // # of samples the stats were calculated from
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// reservoir keeps a bounded ring of the most recent execution durations.
+type reservoir struct {
+	m       sync.Mutex
+	samples [latencySamples]time.Duration
+	n       int
+}
+
+func (r *reservoir) record(d time.Duration) {
+	r.m.Lock()
+	r.samples[r.n%latencySamples] = d
+	r.n++
+	r.m.Unlock()
+}
+
+func (r *reservoir) stats() LatencyStats {
+
+	r.m.Lock()
+
+	n := r.n
+
+	if n > latencySamples {
+		n = latencySamples
+	}
+
+	samples := make([]time.Duration, n)
+	copy(samples, r.samples[:n])
+
+	r.m.Unlock()
+
+	if n == 0 {
+		return LatencyStats{}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var total time.Duration
+
+	for _, d := range samples {
+		total += d
+	}
+
+	return LatencyStats{
+		Count: n,
+		Min:   samples[0],
+		Max:   samples[n-1],
+		Mean:  total / time.Duration(n),
+		P50:   percentile(samples, 50),
+		P95:   percentile(samples, 95),
+		P99:   percentile(samples, 99),
+	}
+}
+
+// percentile returns the nearest rank percentile of the sorted samples.
+func percentile(samples []time.Duration, p int) time.Duration {
+
+	rank := (p*len(samples) + 99) / 100
+
+	if rank < 1 {
+		rank = 1
+	}
+
+	return samples[rank-1]
+}
+
+// QueueWaitStats returns the distribution of the time the most recent, up to 1024, Work Units to
+// have started since the pool was created or last Reset() spent queued waiting for a worker;
+// separate from how long they took to execute, see LatencyStats, a long wait along with a short
+// execution time is a sign the pool needs more workers.
+func (p *Pool) QueueWaitStats() LatencyStats {
+	return p.stats.Load().queueWait.stats()
+}
+
+// LatencyStats returns the distribution of the execution durations of the most recent, up to
+// 1024, Work Units to have completed since the pool was created or last Reset().
+func (p *Pool) LatencyStats() LatencyStats {
+	return p.stats.Load().latency.stats()
+}
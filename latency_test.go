@@ -0,0 +1,114 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestLatencyStats(t *testing.T) {
+
+	var r reservoir
+
+	Equal(t, r.stats(), LatencyStats{})
+
+	// 1ms..100ms
+	for i := 100; i > 0; i-- {
+		r.record(time.Duration(i) * time.Millisecond)
+	}
+
+	s := r.stats()
+
+	Equal(t, s.Count, 100)
+	Equal(t, s.Min, time.Millisecond)
+	Equal(t, s.Max, time.Millisecond*100)
+	Equal(t, s.Mean, time.Microsecond*50500)
+	Equal(t, s.P50, time.Millisecond*50)
+	Equal(t, s.P95, time.Millisecond*95)
+	Equal(t, s.P99, time.Millisecond*99)
+
+	// bounded to the most recent samples
+	for i := 0; i < latencySamples; i++ {
+		r.record(time.Second)
+	}
+
+	s = r.stats()
+
+	Equal(t, s.Count, latencySamples)
+	Equal(t, s.Min, time.Second)
+	Equal(t, s.P99, time.Second)
+
+	pool := New(2)
+	defer pool.Close()
+
+	var res []*WorkUnit
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond * 10)
+			return nil, nil
+		}))
+	}
+
+	WaitAll(res...)
+
+	for pool.Stats().CompletedCount != 10 {
+		time.Sleep(time.Millisecond)
+	}
+
+	s = pool.LatencyStats()
+
+	Equal(t, s.Count, 10)
+	Equal(t, s.Min >= time.Millisecond*10, true)
+
+	// reset along with the pool
+	pool.Cancel()
+	pool.Reset()
+
+	Equal(t, pool.LatencyStats(), LatencyStats{})
+}
+
+func TestQueueWaitStats(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	Equal(t, pool.QueueWaitStats(), LatencyStats{})
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 5)
+		return nil, nil
+	}
+
+	// an idle pool starts work straight away
+	wu := pool.Queue(fn)
+	<-wu.Done
+
+	idle := pool.QueueWaitStats()
+	Equal(t, idle.Count, 1)
+	Equal(t, idle.Max < time.Millisecond*5, true)
+
+	// saturated, each waits on those before it
+	var res []*WorkUnit
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	WaitAll(res...)
+
+	s := pool.QueueWaitStats()
+	Equal(t, s.Count, 11)
+	Equal(t, s.Max >= time.Millisecond*40, true)
+	Equal(t, s.P95 > idle.Max, true)
+	Equal(t, s.Mean > idle.Mean, true)
+
+	// execution latency is unaffected by the wait
+	Equal(t, pool.LatencyStats().Max < time.Millisecond*40, true)
+
+	pool.Close()
+	pool.Reset()
+
+	Equal(t, pool.QueueWaitStats(), LatencyStats{})
+}
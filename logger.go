@@ -0,0 +1,33 @@
+package pool
+
+// Logger is used by the pool to report diagnostic messages, such as a batch being auto completed,
+// the pool being resized or a WorkFunc panicking; it's satisfied by the standard library's
+// *log.Logger and is easily adapted for other loggers such as zap or logrus.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// loggerHolder allows storing different Logger implementations,
+// including nil, in the same atomic.Value.
+type loggerHolder struct {
+	l Logger
+}
+
+// SetLogger sets the logger diagnostic messages are written to, by default
+// and when passed nil they are discarded.
+func (p *Pool) SetLogger(l Logger) {
+	p.logger.Store(loggerHolder{l: l})
+}
+
+// logging reports whether a logger has been set, checked before calling logf so
+// that the arguments aren't allocated when the messages are just discarded.
+func (p *Pool) logging() bool {
+	h, _ := p.logger.Load().(loggerHolder)
+	return h.l != nil
+}
+
+func (p *Pool) logf(format string, args ...interface{}) {
+	if h, _ := p.logger.Load().(loggerHolder); h.l != nil {
+		h.l.Printf(format, args...)
+	}
+}
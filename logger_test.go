@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+type testLogger struct {
+	m    sync.Mutex
+	msgs []string
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.m.Lock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, args...))
+	l.m.Unlock()
+}
+
+func (l *testLogger) logged(pattern string) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	for _, msg := range l.msgs {
+		if ok, _ := regexp.MatchString(pattern, msg); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSetLogger(t *testing.T) {
+
+	pool := NewBounded(1, 1)
+	defer pool.Close()
+
+	logger := new(testLogger)
+	pool.SetLogger(logger)
+
+	pool.Resize(2)
+	Equal(t, logger.logged("resized from 1 to 2 workers"), true)
+
+	pool.Resize(1)
+	Equal(t, logger.logged("resized from 2 to 1 workers"), true)
+
+	wu := pool.Queue(func() (interface{}, error) {
+		panic("oops")
+	})
+	<-wu.Done
+
+	Equal(t, logger.logged(fmt.Sprintf("recovered from panic in Work Unit %d: oops", wu.ID())), true)
+
+	// overflowing the bounded queue from within a WorkFunc
+	var inner []*WorkUnit
+
+	wu = pool.QueueNested(func(queue func(fn WorkFuncNested) *WorkUnit) (interface{}, error) {
+		for i := 0; i < 3; i++ {
+			inner = append(inner, queue(func(func(fn WorkFuncNested) *WorkUnit) (interface{}, error) { return nil, nil }))
+		}
+		return nil, nil
+	})
+	<-wu.Done
+	WaitAll(inner...)
+
+	Equal(t, logger.logged("queued from within a WorkFunc exceeded the bound"), true)
+
+	pool.SetSlowThreshold(time.Millisecond*10, func(*WorkUnit, time.Duration) {})
+
+	wu = pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 50)
+		return nil, nil
+	})
+	<-wu.Done
+
+	Equal(t, logger.logged(fmt.Sprintf("Work Unit %d still running after", wu.ID())), true)
+
+	// discarded once removed
+	pool.SetLogger(nil)
+
+	logger.m.Lock()
+	n := len(logger.msgs)
+	logger.m.Unlock()
+
+	pool.Resize(3)
+
+	logger.m.Lock()
+	Equal(t, len(logger.msgs), n)
+	logger.m.Unlock()
+}
+
+func TestNewWithDeadlineLogged(t *testing.T) {
+
+	pool := NewWithDeadline(1, time.Millisecond*20)
+	defer pool.Close()
+
+	logger := new(testLogger)
+	pool.SetLogger(logger)
+
+	for !pool.IsClosed() {
+		time.Sleep(time.Millisecond)
+	}
+
+	for !logger.logged("deadline of 20ms exceeded") {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoggerNoopAllocations(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	id, d := uint64(1000), time.Second
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if pool.logging() {
+			pool.logf("pool: Work Unit %d still running after %s", id, d)
+		}
+	})
+
+	Equal(t, allocs, float64(0))
+}
@@ -0,0 +1,36 @@
+package pool
+
+import "time"
+
+// MetricsObserver receives push style metrics from the pool, such as for recording
+// Prometheus counters and histograms. The methods are called directly from the producer
+// and worker goroutines without any locking so must be safe for concurrent use and quick.
+type MetricsObserver interface {
+
+	// OnQueue is called when a Work Unit is accepted onto the queue.
+	OnQueue()
+
+	// OnStart is called when a Work Unit begins executing.
+	OnStart()
+
+	// OnComplete is called when a Work Unit finishes executing with the time
+	// it took to execute and it's error, if any.
+	OnComplete(d time.Duration, err error)
+}
+
+// observerHolder allows storing different MetricsObserver implementations,
+// including nil, in the same atomic.Value.
+type observerHolder struct {
+	obs MetricsObserver
+}
+
+// SetMetricsObserver sets the observer the pool reports Work Unit metrics to.
+// Passing nil removes the observer, when not set there is no overhead.
+func (p *Pool) SetMetricsObserver(obs MetricsObserver) {
+	p.metrics.Store(observerHolder{obs: obs})
+}
+
+func (p *Pool) observer() MetricsObserver {
+	h, _ := p.metrics.Load().(observerHolder)
+	return h.obs
+}
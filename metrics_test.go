@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+type testObserver struct {
+	queued    int32
+	started   int32
+	m         sync.Mutex
+	durations []time.Duration
+	errs      []error
+}
+
+func (o *testObserver) OnQueue() {
+	atomic.AddInt32(&o.queued, 1)
+}
+
+func (o *testObserver) OnStart() {
+	atomic.AddInt32(&o.started, 1)
+}
+
+func (o *testObserver) OnComplete(d time.Duration, err error) {
+	o.m.Lock()
+	o.durations = append(o.durations, d)
+	o.errs = append(o.errs, err)
+	o.m.Unlock()
+}
+
+func TestSetMetricsObserver(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	obs := new(testObserver)
+	pool.SetMetricsObserver(obs)
+
+	bad := errors.New("bad")
+
+	a := pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 50)
+		return nil, nil
+	})
+
+	b := pool.Queue(func() (interface{}, error) {
+		return nil, bad
+	})
+
+	c := pool.Queue(func() (interface{}, error) {
+		panic("OMG")
+	})
+
+	WaitAll(a, b, c)
+
+	Equal(t, atomic.LoadInt32(&obs.queued), int32(3))
+	Equal(t, atomic.LoadInt32(&obs.started), int32(3))
+
+	obs.m.Lock()
+	Equal(t, len(obs.durations), 3)
+	Equal(t, obs.durations[0] >= time.Millisecond*50, true)
+	Equal(t, obs.errs[0], nil)
+	Equal(t, obs.errs[1], bad)
+	_, ok := obs.errs[2].(*PanicError)
+	Equal(t, ok, true)
+	obs.m.Unlock()
+
+	pool.SetMetricsObserver(nil)
+
+	<-pool.Queue(func() (interface{}, error) { return nil, nil }).Done
+
+	Equal(t, atomic.LoadInt32(&obs.queued), int32(3))
+}
@@ -0,0 +1,44 @@
+package pool
+
+// WorkFuncNested is the function type needed by the pool for work that queues follow up work on
+// the same pool, using the passed function, whilst it's running, see QueueNested
+type WorkFuncNested func(queue func(fn WorkFuncNested) *WorkUnit) (interface{}, error)
+
+// QueueNested queues the work to be run, and starts processing immediately, passing a function
+// the WorkFunc can call to queue follow up work on the same pool, which in turn is passed the
+// function, eg. for recursive work. Unlike Queue it never blocks on a bounded pool whose queue is
+// full, see NewBounded and NewWithBuffer, as the worker it would block may be the only one able to
+// make room, instead the queue temporarily grows past it's bound. The follow up work isn't waited
+// on, see QueueSpawn for that.
+//
+// NOTE: the function should only be called whilst the WorkFunc is running, otherwise the bound no
+// longer applies; it may however be called from another goroutine, eg. one started by the WorkFunc.
+// Queue called from within a WorkFunc blocks as it would anywhere else, it's only by using
+// QueueNested that queuing from within a WorkFunc is safe.
+func (p *Pool) QueueNested(fn WorkFuncNested) *WorkUnit {
+	return p.enqueue(p.nestedUnit(fn, false))
+}
+
+// nestedUnit returns a Work Unit to run fn, passing it the function to queue follow up work,
+// whether it may exceed the pool's bound itself is down to whether it too is follow up work.
+func (p *Pool) nestedUnit(fn WorkFuncNested, nested bool) *WorkUnit {
+
+	wu := &WorkUnit{
+		Done:   make(chan struct{}),
+		nested: nested,
+	}
+
+	// left without a WorkFunc so that it's rejected as any other nil WorkFunc
+	if fn != nil {
+		wu.fn = func() (interface{}, error) {
+			return fn(p.queueNested)
+		}
+	}
+
+	return wu
+}
+
+// queueNested queues the follow up work without blocking should the pool's queue be full.
+func (p *Pool) queueNested(fn WorkFuncNested) *WorkUnit {
+	return p.enqueue(p.nestedUnit(fn, true))
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package pool
+
+// raceEnabled is whether built using the race detector, enabling extra checks, see SingleProducer.
+const raceEnabled = false
@@ -1,29 +1,63 @@
 package pool
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"gopkg.in/go-playground/pool.v2/clock"
 )
 
 const (
-	errCancelled = "ERROR: Work Unit Cancelled"
-	errRecovery  = "ERROR: Work Unit failed due to a recoverable error: '%v'\n, Stack Trace:\n %s"
-	errClosed    = "ERROR: Work Unit added/run after the pool had been closed or cancelled"
+	errCancelled      = "ERROR: Work Unit Cancelled"
+	errRecovery       = "ERROR: Work Unit failed due to a recoverable error: '%v'\n, Stack Trace:\n %s"
+	errClosed         = "ERROR: Work Unit added/run after the pool had been closed or cancelled"
+	errTimeout        = "ERROR: Work Unit timed out before completing"
+	errDeadline       = "ERROR: Work Unit cancelled as the pool's deadline was exceeded"
+	errQueueFull      = "ERROR: Work Unit not queued as the pool's queue is full"
+	errQueueTimeout   = "ERROR: Work Unit not queued as the pool's queue stayed full for longer than the queue timeout"
+	errBatches        = "ERROR: pool not reset as batches still have results to be read"
+	errBatchClosed    = "ERROR: Work Unit not queued as the batch's QueueComplete() has already been called"
+	errBatchCancelled = "ERROR: Work Unit not queued as the batch has been cancelled"
+	errCost           = "ERROR: Work Unit not queued as it's cost exceeds the pool's cost limit"
+	errNilFunc        = "ERROR: Work Unit not queued as it's WorkFunc is nil"
+	errRequeueLimit   = "ERROR: Work Unit not requeued as it's already been requeued %d times"
 )
 
-// ErrRecovery contains the error when a consumer goroutine needed to be recovers
-type ErrRecovery struct {
-	s string
+// PanicError is the error set on a Work Unit when it's WorkFunc panics, it contains the
+// recovered value and the stack trace captured when the consumer goroutine recovered,
+// allowing panics to be told apart from errors returned by the WorkFunc using errors.As.
+type PanicError struct {
+	Value  interface{}
+	Stack  []byte
+	UnitID uint64 // ID of the Work Unit that panicked, see WorkUnit.ID()
+	Label  string // Label of the Work Unit that panicked, see WorkUnit.Label()
+	s      string
 }
 
 // Error prints recovery error
-func (e *ErrRecovery) Error() string {
+func (e *PanicError) Error() string {
 	return e.s
 }
 
+// Unwrap returns the recovered value when it is an error, eg. panic(err), otherwise nil.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ErrRecovery contains the error when a consumer goroutine needed to be recovers
+//
+// Deprecated: ErrRecovery is kept for backwards compatibility, use PanicError.
+type ErrRecovery = PanicError
+
 // ErrPoolClosed is the error returned to all work units that may have been in or added to the pool after it's closing.
 type ErrPoolClosed struct {
 	s string
@@ -44,200 +78,1787 @@ func (e *ErrCancelled) Error() string {
 	return e.s
 }
 
+// ErrWorkTimeout is the error returned to a Work Unit when it's WorkFunc did not complete
+// within the duration given to QueueWithTimeout.
+type ErrWorkTimeout struct {
+	s string
+}
+
+// Error prints Work Unit Timeout error
+func (e *ErrWorkTimeout) Error() string {
+	return e.s
+}
+
+// ErrQueueFull is the error returned by QueueOrError when the pool is bounded and it's queue is full.
+type ErrQueueFull struct {
+	s string
+}
+
+// Error prints Work Unit Queue Full error
+func (e *ErrQueueFull) Error() string {
+	return e.s
+}
+
+// ErrQueueTimeout is the error set on a Work Unit that couldn't be queued as the pool's queue
+// stayed full for longer than the queue timeout, see SetQueueTimeout.
+type ErrQueueTimeout struct {
+	s string
+}
+
+// Error prints Work Unit Queue Timeout error
+func (e *ErrQueueTimeout) Error() string {
+	return e.s
+}
+
+// ErrCostTooHigh is the error returned by QueueWithCost when the Work Unit's cost exceeds the
+// pool's cost limit, so could never be queued.
+type ErrCostTooHigh struct {
+	s string
+}
+
+// Error prints Work Unit Cost error
+func (e *ErrCostTooHigh) Error() string {
+	return e.s
+}
+
+// ErrNilWorkFunc is the error set on a Work Unit queued with a nil WorkFunc, it's Done
+// immediately without ever being run.
+type ErrNilWorkFunc struct {
+	s string
+}
+
+// Error prints Work Unit nil WorkFunc error
+func (e *ErrNilWorkFunc) Error() string {
+	return e.s
+}
+
+// ErrBatchesOpen is the error returned by Reset when any of the pool's batches still have
+// results to be read, see ResetForce.
+type ErrBatchesOpen struct {
+	s string
+}
+
+// Error prints Batches Open error
+func (e *ErrBatchesOpen) Error() string {
+	return e.s
+}
+
+// ErrBatchComplete is the error returned by Batch.QueueChecked when the batch's QueueComplete()
+// has already been called.
+type ErrBatchComplete struct {
+	s string
+}
+
+// Error prints Batch Complete error
+func (e *ErrBatchComplete) Error() string {
+	return e.s
+}
+
+// ErrBatchCancelled is the error returned by Batch.QueueChecked when the batch has already been
+// cancelled, see Batch.Cancel.
+type ErrBatchCancelled struct {
+	s string
+}
+
+// Error prints Batch Cancelled error
+func (e *ErrBatchCancelled) Error() string {
+	return e.s
+}
+
+// ErrDeadlineExceeded is the error returned to all Work Units queued or running when a pool's
+// deadline, see NewWithDeadline, is exceeded.
+type ErrDeadlineExceeded struct {
+	s string
+}
+
+// Error prints Work Unit Deadline error
+func (e *ErrDeadlineExceeded) Error() string {
+	return e.s
+}
+
 // WorkUnit contains a single unit of works values
 type WorkUnit struct {
-	Value     interface{}
-	Error     error
-	Done      chan struct{}
-	fn        WorkFunc
-	cancelled atomic.Value
-	running   atomic.Value
+	Value        interface{}
+	Error        error
+	Done         chan struct{}
+	id           uint64
+	label        string
+	input        interface{}
+	pool         *Pool
+	index        int
+	fn           WorkFunc
+	fnCtx        WorkFuncCtx
+	fnState      WorkFuncState
+	fnCancel     WorkFuncCancellable
+	fnMulti      WorkFuncMulti
+	fnProgress   WorkFuncProgress
+	ctx          context.Context
+	priority     int
+	seq          uint64
+	timeout      time.Duration
+	deadline     time.Time
+	retries      int
+	queued       time.Time // when accepted onto the queue, for QueueWaitStats
+	started      time.Time // when picked up by a worker, guarded by the pool's lock
+	slow         bool
+	cost         int64
+	offHeap      bool   // queued outside of the heap, eg. in the ring, see remove
+	pinned       bool   // run on the worker chosen by key, see QueueAffinity
+	key          uint64 // the affinity key, see QueueAffinity
+	backoff      func(attempt int) time.Duration
+	attempts     int32
+	finished     uint32
+	done         uint32
+	state        uint32
+	stop         uint32
+	settled      uint32
+	progress     uint64       // math.Float64bits of the last reported progress
+	mws          []Middleware // the batch's middleware, see Batch.Use
+	token        *CancelToken
+	gen          uint64        // barrier generation, see Barrier
+	counted      uint8         // whether counted towards the barrier generation, see stamp
+	completed    time.Time     // when completed, only set when retained, see SetResultTTL
+	requeues     int32         // # of times requeued, see ErrRequeue
+	requeueLimit int           // 0 for the default, see QueueWithRequeueLimit
+	flow         *flow         // the batch's flow, see NewFair
+	tag          uint64        // when fair, the virtual time it's dispatched by, see NewFair
+	stream       io.ReadCloser // the WorkFuncStream's output, see QueueStream
+	nested       bool          // queued from within a WorkFunc so may exceed the bound, see QueueNested
+	delivered    chan struct{} // closed once it's batch has delivered it's result, see SetResultBuffer
+	shared       bool          // held on to by a batch, Future, Then or QueueDedup, see ReleaseUnit
+	generation   uint64        // bumped each time it's recycled, see ReleaseUnit
 }
 
-// Cancel cancels this specific unit of work.
-func (wu *WorkUnit) Cancel() {
-	wu.cancelWithError(&ErrCancelled{s: errCancelled})
+// Work Unit states, a Work Unit moves from queued to either running or cancelled but never both.
+const (
+	stateQueued uint32 = iota
+	stateRunning
+	stateCancelled
+)
+
+// ID returns the Work Unit's identifier, assigned from an increasing counter when it's queued,
+// which is unique within the pool that queued it, even across calls to Reset(), and useful
+// for correlating a Work Unit across logs and the pool's hooks.
+func (wu *WorkUnit) ID() uint64 {
+	return wu.id
 }
 
-func (wu *WorkUnit) cancelWithError(err error) {
-	if wu.running.Load() == nil && wu.cancelled.Load() == nil {
-		wu.cancelled.Store(struct{}{})
-		wu.Error = err
-		close(wu.Done)
+// Context returns the context the Work Unit was queued with, see QueueCtx, or
+// context.Background() for Work Units queued without one.
+func (wu *WorkUnit) Context() context.Context {
+
+	if wu.ctx == nil {
+		return context.Background()
 	}
+
+	return wu.ctx
 }
 
-// WorkFunc is the function type needed by the pool
-type WorkFunc func() (interface{}, error)
+// Input returns the input the Work Unit was queued with, see Batch.QueueWith, which is
+// nil for Work Units queued by any other means.
+func (wu *WorkUnit) Input() interface{} {
+	return wu.input
+}
 
-// Pool in the main pool instance.
-type Pool struct {
-	workers uint
-	work    chan *WorkUnit
-	cancel  chan struct{}
-	closed  bool
-	m       *sync.RWMutex
+// Label returns the label the Work Unit was queued with, see QueueLabeled, which is
+// empty for Work Units queued by any other means.
+func (wu *WorkUnit) Label() string {
+	return wu.label
 }
 
-// New returns a new pool instance.
-func New(workers uint) *Pool {
+// Values returns the values returned by a WorkFuncMulti, see QueueMulti, for Work Units queued
+// by any other means it returns the Value as the only element, or nil when there is no Value.
+func (wu *WorkUnit) Values() []interface{} {
 
-	if workers == 0 {
-		panic("invalid workers '0'")
+	if wu.fnMulti != nil {
+		vs, _ := wu.Value.([]interface{})
+		return vs
 	}
 
-	p := &Pool{
-		workers: workers,
-		m:       new(sync.RWMutex),
+	if wu.Value == nil {
+		return nil
 	}
 
-	p.initialize()
+	return []interface{}{wu.Value}
+}
 
-	return p
+// callMulti runs the WorkFuncMulti, it's values are stored as the Work Unit's Value.
+func (wu *WorkUnit) callMulti() (interface{}, error) {
+
+	vs, err := wu.fnMulti()
+
+	if vs == nil {
+		return nil, err
+	}
+
+	return vs, err
 }
 
-func (p *Pool) initialize() {
+// IsDone reports, without blocking, whether the Work Unit has completed; once true it's
+// Value and Error are set and safe to read.
+func (wu *WorkUnit) IsDone() bool {
+	return atomic.LoadUint32(&wu.done) == 1
+}
 
-	p.work = make(chan *WorkUnit, p.workers*2)
-	p.cancel = make(chan struct{})
-	p.closed = false
+// Wait waits at most d for the Work Unit to complete, reporting whether it has; the Work Unit
+// isn't cancelled should it still be running and it's Done channel is left untouched, so it can
+// still be waited on afterwards.
+func (wu *WorkUnit) Wait(d time.Duration) bool {
 
-	// fire up workers here
-	for i := 0; i < int(p.workers); i++ {
-		p.newWorker(p.work, p.cancel)
+	// already done, even should d have elapsed
+	select {
+	case <-wu.Done:
+		return true
+	default:
+	}
+
+	c := clock.Real
+
+	if wu.pool != nil {
+		c = wu.pool.clk()
+	}
+
+	t := c.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-wu.Done:
+		return true
+	case <-t.C():
+		return false
+	}
+}
+
+// Cancel cancels this specific unit of work. A Work Unit that has yet to start is removed from
+// the queue, so that a worker never picks it up, and it's Error set to ErrCancelled. A Work Unit
+// that is already running can't be stopped, but a WorkFuncCancellable can check for the
+// cancellation using the function it's passed, see QueueCancellable.
+func (wu *WorkUnit) Cancel() {
+	wu.cancelCause(&ErrCancelled{s: errCancelled})
+}
+
+// cancelCause is the same as Cancel() but sets the error of a Work Unit that is yet to start.
+func (wu *WorkUnit) cancelCause(err error) {
+
+	if wu.cancelWithError(err) {
+
+		settle := true
+
+		if wu.pool != nil {
+			settle = wu.pool.remove(wu)
+		}
+
+		if settle {
+			wu.settle()
+		}
+
+		return
 	}
+
+	atomic.StoreUint32(&wu.stop, 1)
 }
 
-// passing work and cancel channels to newWorker() to avoid any potential race condition
-// betweeen p.work read & write
-func (p *Pool) newWorker(work chan *WorkUnit, cancel chan struct{}) {
-	go func(p *Pool) {
+// cancelWithError cancels the Work Unit, returning whether it was still queued.
+func (wu *WorkUnit) cancelWithError(err error) bool {
 
-		var wu *WorkUnit
+	if atomic.CompareAndSwapUint32(&wu.state, stateQueued, stateCancelled) {
 
-		defer func(p *Pool) {
-			if err := recover(); err != nil {
+		wu.complete(nil, err)
+
+		if wu.pool != nil {
+			wu.pool.emit(EventCancelled, wu.id, 0, err)
+		}
+
+		return true
+	}
+
+	return false
+}
 
-				trace := make([]byte, 1<<16)
-				n := runtime.Stack(trace, true)
+// settle marks the Work Unit as no longer being referenced by the pool, so it may be
+// recycled by ReleaseUnit.
+func (wu *WorkUnit) settle() {
+	atomic.StoreUint32(&wu.settled, 1)
+}
 
-				s := fmt.Sprintf(errRecovery, err, string(trace[:int(math.Min(float64(n), float64(7000)))]))
+// cancelled reports whether Cancel() has been called on the running Work Unit.
+func (wu *WorkUnit) cancelled() bool {
+	return atomic.LoadUint32(&wu.stop) == 1
+}
 
-				iwu := wu
-				iwu.Error = &ErrRecovery{s: s}
-				close(iwu.Done)
+// complete sets the Work Unit's results and closes it's Done channel, only the first call
+// has any effect so that a Work Unit that has been abandoned, by the pool being shutdown for
+// example, isn't touched again once it's WorkFunc eventually returns.
+func (wu *WorkUnit) complete(value interface{}, err error) bool {
 
-				// need to fire up new worker to replace this one as this one is exiting
-				p.newWorker(p.work, p.cancel)
-			}
-		}(p)
+	if !atomic.CompareAndSwapUint32(&wu.finished, 0, 1) {
+		return false
+	}
+
+	wu.Value, wu.Error = value, err
+
+	// only once the results have been set
+	atomic.StoreUint32(&wu.done, 1)
+
+	// released before Done so those waiting on it can rely on the budget being free
+	if wu.cost > 0 {
+		wu.pool.costs.release(wu.cost)
+	}
+
+	if wu.token != nil {
+		wu.token.remove(wu)
+	}
+
+	if wu.pool != nil {
+		wu.pool.unstamp(wu)
+	}
+
+	if wu.pool != nil {
+		wu.pool.retain(wu)
+	}
+
+	// who knows where the Done channel is being listened to on the other end
+	// don't want this to block just because caller is waiting on another unit
+	// of work to be done first so we use close
+	close(wu.Done)
+
+	return true
+}
+
+// call runs the Work Unit's WorkFunc, passing the context or worker state if it needs them,
+// wrapped in any middleware registered on the pool.
+func (p *Pool) call(wu *WorkUnit, ctx context.Context, state interface{}) (interface{}, error) {
+
+	mws, _ := p.middleware.Load().([]Middleware)
+	cmws, _ := p.ctxMiddleware.Load().([]MiddlewareCtx)
+	bmws := wu.mws
 
-		for {
-			select {
-			case wu = <-work:
+	if len(mws) == 0 && len(cmws) == 0 && len(bmws) == 0 {
+		return wu.run(ctx, state)
+	}
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		return wu.run(ctx, state)
+	}
+
+	// context middleware wraps on the outside so that the context it derives
+	// is the one in effect by the time the regular middleware and WorkFunc run
+	if len(mws) > 0 || len(bmws) > 0 {
 
-				// possible for one more nilled out value to make it
-				// through when channel closed, don't quite understad the why
-				if wu == nil {
-					continue
-				}
+		inner := fn
 
-				wu.running.Store(struct{}{})
+		fn = func(ctx context.Context) (interface{}, error) {
 
-				// support for individual WorkUnit cancellation
-				// and batch job cancellation
-				if wu.cancelled.Load() == nil {
-					wu.Value, wu.Error = wu.fn()
+			next := func() (interface{}, error) {
+				return inner(ctx)
+			}
 
-					// who knows where the Done channel is being listened to on the other end
-					// don't want this to block just because caller is waiting on another unit
-					// of work to be done first so we use close
-					close(wu.Done)
-				}
+			// the batch's middleware wraps inside of the pool's
+			for i := len(bmws) - 1; i >= 0; i-- {
+				next = bmws[i](next)
+			}
 
-			case <-cancel:
-				return
+			for i := len(mws) - 1; i >= 0; i-- {
+				next = mws[i](next)
 			}
+
+			return next()
 		}
+	}
 
-	}(p)
+	for i := len(cmws) - 1; i >= 0; i-- {
+		fn = cmws[i](fn)
+	}
+
+	return fn(ctx)
 }
 
-// Queue queues the work to be run, and starts processing immediately
-func (p *Pool) Queue(fn WorkFunc) *WorkUnit {
+// hasFunc reports whether the Work Unit was queued with a WorkFunc, of any type, to run.
+func (wu *WorkUnit) hasFunc() bool {
+	return wu.fn != nil || wu.fnCtx != nil || wu.fnState != nil || wu.fnCancel != nil || wu.fnMulti != nil ||
+		wu.fnProgress != nil
+}
 
-	w := &WorkUnit{
-		Done: make(chan struct{}),
-		fn:   fn,
+// run calls whichever type of WorkFunc the Work Unit was queued with.
+func (wu *WorkUnit) run(ctx context.Context, state interface{}) (interface{}, error) {
+	switch {
+	case wu.fnCtx != nil:
+		return wu.fnCtx(ctx)
+	case wu.fnState != nil:
+		return wu.fnState(state)
+	case wu.fnCancel != nil:
+		return wu.fnCancel(wu.cancelled)
+	case wu.fnProgress != nil:
+		return wu.fnProgress(wu.reportProgress)
+	case wu.fnMulti != nil:
+		return wu.callMulti()
 	}
+	return wu.fn()
+}
 
-	go func() {
-		p.m.RLock()
-		if p.closed {
-			w.Error = &ErrPoolClosed{s: errClosed}
-			if w.cancelled.Load() == nil {
-				close(w.Done)
-			}
-			p.m.RUnlock()
+// Progress returns the progress last reported by the Work Unit's WorkFuncProgress, 0 if it's
+// yet to report any or was queued by other means, see QueueWithProgress.
+func (wu *WorkUnit) Progress() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&wu.progress))
+}
+
+// reportProgress is passed to the WorkFuncProgress, just an atomic store so it's cheap to call often.
+func (wu *WorkUnit) reportProgress(pct float64) {
+	atomic.StoreUint64(&wu.progress, math.Float64bits(pct))
+}
+
+// Attempts returns the number of times the Work Unit's WorkFunc has been executed,
+// which will only ever be greater than 1 when queued using QueueWithRetry.
+func (wu *WorkUnit) Attempts() int {
+	return int(atomic.LoadInt32(&wu.attempts))
+}
+
+// execute runs the Work Unit's WorkFunc, retrying it if requested and stopping
+// immediately should the pool be cancelled in between attempts.
+func (p *Pool) execute(wu *WorkUnit, ctx context.Context, w *worker) (value interface{}, err error) {
+
+	for {
+		attempt := int(atomic.AddInt32(&wu.attempts, 1))
+
+		if wu.timeout > 0 || !wu.deadline.IsZero() {
+			value, err = p.runWithTimeout(wu, ctx, w)
+		} else {
+			value, err = p.call(wu, ctx, w.workerState(p))
+		}
+
+		// requeuing isn't a failed attempt
+		if err == nil || attempt >= wu.retries || errors.Is(err, ErrRequeue) {
 			return
 		}
 
-		p.work <- w
+		var d time.Duration
 
-		p.m.RUnlock()
-	}()
+		if wu.backoff != nil {
+			d = wu.backoff(attempt)
+		}
 
-	return w
+		t := p.clk().NewTimer(d)
+
+		select {
+		case <-w.cancel:
+			t.Stop()
+			return nil, &ErrCancelled{s: errCancelled}
+		case <-t.C():
+		}
+
+		// timer and cancel may both be ready, don't start another attempt if cancelled
+		select {
+		case <-w.cancel:
+			return nil, &ErrCancelled{s: errCancelled}
+		default:
+		}
+	}
 }
 
-// Reset reinitializes a pool that has been closed/cancelled back to a working state.
-// if the pool has not been closed/cancelled, nothing happens as the pool is still in
-// a valid running state
-func (p *Pool) Reset() {
+// WaitAll blocks until every passed Work Unit is Done and returns their errors
+// aligned by index with the passed Work Units, nil for those that succeeded.
+func WaitAll(units ...*WorkUnit) []error {
 
-	p.m.Lock()
+	errs := make([]error, len(units))
 
-	if !p.closed {
-		p.m.Unlock()
-		return
+	for i, wu := range units {
+		<-wu.Done
+		errs[i] = wu.Error
 	}
 
-	// cancelled the pool, not closed it, pool will be usable after calling initialize().
-	p.initialize()
-	p.m.Unlock()
+	return errs
 }
 
-func (p *Pool) closeWithError(err error) {
+// WorkFunc is the function type needed by the pool
+type WorkFunc func() (interface{}, error)
 
-	p.m.Lock()
+// WorkFuncCtx is the function type needed by the pool for context aware work
+type WorkFuncCtx func(ctx context.Context) (interface{}, error)
 
-	if !p.closed {
-		close(p.cancel)
-		close(p.work)
-		p.closed = true
+// WorkFuncState is the function type needed by the pool for work that uses
+// the state of the worker running it, see NewWithWorkerState
+type WorkFuncState func(state interface{}) (interface{}, error)
+
+// WorkFuncMulti is the function type needed by the pool for work that returns multiple values
+type WorkFuncMulti func() ([]interface{}, error)
+
+// WorkFuncCancellable is the function type needed by the pool for long running work that
+// checks, using the passed function, whether it's Work Unit has been cancelled whilst running
+type WorkFuncCancellable func(cancelled func() bool) (interface{}, error)
+
+// WorkFuncProgress is the function type needed by the pool for long running work that reports
+// how far through it is, using the passed function, whilst running, eg. report(40) for 40% done
+type WorkFuncProgress func(report func(pct float64)) (interface{}, error)
+
+// WorkFuncStream is the function type needed by the pool for work that streams it's output,
+// written to the passed writer, rather than returning it, see QueueStream
+type WorkFuncStream func(w io.Writer) error
+
+// Middleware wraps a WorkFunc, calling next to continue on to the wrapped WorkFunc
+type Middleware func(next WorkFunc) WorkFunc
+
+// MiddlewareCtx wraps a context aware WorkFunc, calling next, with the context or one derived
+// from it, to continue on to the wrapped WorkFunc
+type MiddlewareCtx func(next WorkFuncCtx) WorkFuncCtx
+
+// Pool in the main pool instance.
+type Pool struct {
+	workers   uint
+	maxQueued uint
+	queueWait time.Duration // how long Queue waits for room, 0 forever, see SetQueueTimeout
+	handoff   bool
+	strategy  WaitStrategy
+	idle      uint
+	workerID  int
+	queue     workQueue
+	seq       uint64
+	ids       uint64
+	cond      *sync.Cond
+	notFull   *sync.Cond
+	drained   *sync.Cond
+	idleCh    chan struct{} // closed once idle, see Idle
+	roomCh    chan struct{} // closed once there's room in the queue, see CanQueue
+	inline    bool          // run Work Units on the goroutine queuing them, see NewSync
+	stealing  bool          // queue on the workers' own queues, see NewWorkStealing
+	turn      uint64        // the last worker queued on, see pushLocal
+	fair      bool          // dispatch fairly between batches, see NewFair
+	vtime     uint64        // the fair tag of the last Work Unit dequeued, see NewFair
+	unbatched flow          // the flow of Work Units not queued on a batch, see NewFair
+	active    map[*WorkUnit]struct{}
+	cancel    chan struct{}
+	quits     []chan struct{}
+	stats     atomic.Pointer[stats] // replaced by Reset(), loaded without the lock, see Stats
+	closed    bool
+	cancelled bool
+	draining  bool
+	drainStat uint32 // 1 whilst draining and not yet closed, for Stats() without the lock
+	paused    bool
+	m         *sync.RWMutex
+	ring      *ring
+	single    uint32
+	open      uint32 // whether accepting work, for checking without the lock when using the ring
+	producing uint32
+	sleeping  int32     // # of idle workers waiting on the ring
+	slots     []*worker // the current workers, in the same order as quits
+	local     int       // # of Work Units in the workers' affinity queues, see QueueAffinity
+	live      int32     // # of consumer goroutines running, see WorkerCount
+
+	panicHandler  atomic.Value
+	limiter       atomic.Value
+	tracer        atomic.Value
+	metrics       atomic.Value
+	classifier    atomic.Value
+	clock         atomic.Value // clockHolder, see SetClock
+	middleware    atomic.Value
+	ctxMiddleware atomic.Value
+	logger        atomic.Value
+	starter       atomic.Value
+	workerStart   atomic.Value
+	workerStop    atomic.Value
+	events        atomic.Value
+	wrap          uint32
+	stateFactory  func() interface{}
+	dedup         map[string]*WorkUnit
+	dm            sync.Mutex
+	batches       map[*Batch]struct{}
+	parent        *Pool
+	children      map[*Pool]struct{}
+	deadline      time.Duration
+	timer         clock.Timer
+
+	costs         *costs
+	barriers      barriers
+	slowThreshold time.Duration
+	slowHandler   func(*WorkUnit, time.Duration)
+	slowStop      chan struct{}
+	retained      retained
+	breaker       breaker
+	adaptive      *adaptive // see EnableAdaptiveConcurrency
+}
+
+// New returns a new pool instance.
+func New(workers uint) *Pool {
+	return newPool(workers, 0)
+}
+
+// NewAuto returns a new pool instance with a worker per CPU, see runtime.NumCPU(), suited to
+// CPU bound work. The # of workers is captured when created and doesn't change should the # of
+// CPUs available, use Resize() for that.
+func NewAuto() *Pool {
+	return NewAutoMultiple(1)
+}
+
+// NewAutoMultiple returns a new pool instance with factor workers per CPU, see NewAuto(), rounded
+// and at least 1; eg. 4 for IO bound work that spends most of it's time waiting.
+func NewAutoMultiple(factor float64) *Pool {
+
+	if factor <= 0 {
+		panic(fmt.Sprintf("invalid factor '%v'", factor))
+	}
+
+	return newPool(autoWorkers(runtime.NumCPU(), factor), 0)
+}
+
+// autoWorkers returns factor workers per CPU, rounded and at least 1.
+func autoWorkers(cpus int, factor float64) uint {
+
+	workers := uint(math.Round(float64(cpus) * factor))
+
+	if workers == 0 {
+		workers = 1
 	}
 
-	for wu := range p.work {
-		wu.cancelWithError(err)
+	return workers
+}
+
+// NewBounded returns a new pool instance whose queue holds at most maxQueued Work Units
+// waiting for a worker; once full Queue blocks until there is room, applying backpressure
+// to the producer, and TryQueue returns immediately without queuing the work.
+// Work queued from within a running WorkFunc using the function passed to a WorkFuncNested, see
+// QueueNested, never blocks, as that could leave no worker free to make room, instead the queue
+// temporarily grows past maxQueued.
+//
+// WARNING: only QueueNested is safe to queue from within a WorkFunc, Queue and all of the other
+// means of queuing work can't tell that they're called by a worker and so block as they would
+// anywhere else, deadlocking the pool should every worker be blocked.
+func NewBounded(workers, maxQueued uint) *Pool {
+
+	if maxQueued == 0 {
+		panic("invalid maxQueued '0'")
+	}
+
+	return newPool(workers, maxQueued)
+}
+
+// SetQueueTimeout sets how long Queue, and every other means of queuing work, waits for room in a
+// bounded pool's full queue, see NewBounded, before giving up and completing the Work Unit with an
+// ErrQueueTimeout error without it having been queued; a safety valve against producers hanging
+// forever should the workers be stuck, distinct from how long the work itself may run for, see
+// QueueWithTimeout. A timeout of 0, the default, waits forever.
+func (p *Pool) SetQueueTimeout(d time.Duration) {
+
+	if d < 0 {
+		panic(fmt.Sprintf("invalid timeout '%s'", d))
 	}
 
+	p.m.Lock()
+	p.queueWait = d
 	p.m.Unlock()
 }
 
-// Cancel cleans up the pool workers and channels and cancels and pending
-// work still yet to be processed.
-// call Reset() to reinitialize the pool for use.
-func (p *Pool) Cancel() {
+// NewWithWorkerState returns a new pool instance where factory is called once for each worker to
+// create state, such as a database connection or buffer, that lives for the lifetime of the worker
+// and is passed to each WorkFuncState the worker runs, see QueueWithState, avoiding the need to
+// create expensive resources per Work Unit; Work Units queued by other means ignore the state.
+//
+// NOTE: only the worker that created the state ever uses it, so it must not be shared elsewhere.
+// A worker's state is discarded and created anew should a WorkFunc panic or timeout whilst using it.
+func NewWithWorkerState(workers uint, factory func() interface{}) *Pool {
 
-	err := &ErrCancelled{s: errCancelled}
-	p.closeWithError(err)
+	p := newPool(workers, 0)
+	p.stateFactory = factory
+
+	return p
 }
 
-// Close cleans up the pool workers and channels and cancels any pending
-// work still yet to be processed.
-// call Reset() to reinitialize the pool for use.
-func (p *Pool) Close() {
+// NewWithDeadline returns a new pool instance that is cancelled once d has elapsed since it was
+// created, or last Reset(), guarding against runaway jobs; all Work Units still queued or running,
+// the latter being abandoned, have their Done channels closed with an ErrDeadlineExceeded error.
+func NewWithDeadline(workers uint, d time.Duration) *Pool {
 
-	err := &ErrPoolClosed{s: errClosed}
-	p.closeWithError(err)
+	if d <= 0 {
+		panic(fmt.Sprintf("invalid deadline '%s'", d))
+	}
+
+	p := newPool(workers, 0)
+
+	p.m.Lock()
+	p.deadline = d
+	p.startDeadline()
+	p.m.Unlock()
+
+	return p
+}
+
+// NewWithBuffer returns a new pool instance where at most bufferSize Work Units wait in the queue
+// for a worker before Queue blocks, see NewBounded. A bufferSize of 0 hands each Work Unit directly
+// to an idle worker, blocking until one is free, which keeps memory to a minimum and the latency
+// between queuing and starting low, but producers wait on the workers; larger buffers let producers
+// run ahead, and workers not starve between bursts, at the cost of holding more Work Units in memory.
+// New() has an unbounded buffer that never blocks.
+func NewWithBuffer(workers, bufferSize uint) *Pool {
+
+	if bufferSize > 0 {
+		return newPool(workers, bufferSize)
+	}
+
+	p := newPool(workers, 0)
+
+	p.m.Lock()
+	p.handoff = true
+	p.m.Unlock()
+
+	return p
+}
+
+func newPool(workers, maxQueued uint) *Pool {
+
+	if workers == 0 {
+		panic("invalid workers '0'")
+	}
+
+	p := &Pool{
+		workers:   workers,
+		maxQueued: maxQueued,
+		active:    make(map[*WorkUnit]struct{}),
+		m:         new(sync.RWMutex),
+	}
+
+	p.cond = sync.NewCond(p.m)
+	p.notFull = sync.NewCond(p.m)
+	p.drained = sync.NewCond(p.m)
+	p.initialize()
+
+	return p
+}
+
+func (p *Pool) initialize() {
+
+	p.cancel = make(chan struct{})
+	p.stats.Store(new(stats))
+	p.quits = make([]chan struct{}, 0, p.workers)
+	p.slots = make([]*worker, 0, p.workers)
+	p.closed = false
+	p.cancelled = false
+	p.draining = false
+	atomic.StoreUint32(&p.drainStat, 0)
+	p.paused = false
+	atomic.StoreUint32(&p.open, 1)
+
+	if p.deadline > 0 {
+		p.startDeadline()
+	}
+
+	if p.slowThreshold > 0 {
+		p.startSlowWatcher()
+	}
+
+	if p.adaptive != nil {
+		p.startAdaptive()
+	}
+
+	if atomic.LoadInt64(&p.retained.ttl) > 0 {
+		p.startJanitor()
+	}
+
+	// fire up workers here
+	p.grow(p.workers)
+}
+
+// startDeadline starts the timer that cancels the current generation of the pool once the
+// deadline has elapsed, must be called with the lock held.
+func (p *Pool) startDeadline() {
+
+	cancel := p.cancel
+
+	p.timer = p.clk().AfterFunc(p.deadline, func() {
+
+		p.m.Lock()
+
+		// the pool has since been closed and possibly reset
+		if p.closed || p.cancel != cancel {
+			p.m.Unlock()
+			return
+		}
+
+		err := &ErrDeadlineExceeded{s: errDeadline}
+
+		p.abandonActive(err)
+		children := p.closeLocked(err)
+		p.m.Unlock()
+
+		if p.logging() {
+			p.logf("pool: deadline of %s exceeded, cancelled all queued and running Work Units", p.deadline)
+		}
+
+		p.cascade(children, err)
+	})
+}
+
+// grow fires up n more workers, each with their own quit channel so that they
+// can be individually stopped when shrinking the pool.
+func (p *Pool) grow(n uint) {
+	for i := uint(0); i < n; i++ {
+		p.workerID++
+
+		w := &worker{
+			cancel: p.cancel,
+			quit:   make(chan struct{}),
+			stats:  p.stats.Load(),
+			id:     p.workerID,
+		}
+		p.quits = append(p.quits, w.quit)
+		p.slots = append(p.slots, w)
+		p.newWorker(w)
+		p.emit(EventWorkerStarted, 0, w.id, nil)
+	}
+}
+
+// recoveryError converts a recovered WorkFunc panic into the Work Unit's error,
+// first passing it to the panic handler if one has been set.
+func (p *Pool) recoveryError(wu *WorkUnit, err interface{}) *PanicError {
+
+	trace := make([]byte, 1<<16)
+	n := runtime.Stack(trace, true)
+
+	if h, ok := p.panicHandler.Load().(func(interface{}, []byte)); ok && h != nil {
+		p.callPanicHandler(h, err, trace[:n])
+	}
+
+	if p.logging() {
+		p.logf("pool: recovered from panic in Work Unit %d: %v", wu.id, err)
+	}
+
+	return &PanicError{
+		Value:  err,
+		Stack:  trace[:n],
+		UnitID: wu.id,
+		Label:  wu.label,
+		s:      fmt.Sprintf(errRecovery, err, string(trace[:int(math.Min(float64(n), float64(7000)))])),
+	}
+}
+
+// runWithTimeout runs the WorkFunc in it's own goroutine so that the worker can be
+// released back to the pool once the timeout, or deadline, has elapsed. The WorkFunc cannot be
+// forcibly stopped, it is abandoned and left to finish on it's own with it's results discarded,
+// however the context passed to a context aware WorkFunc carries the deadline so it can stop itself.
+func (p *Pool) runWithTimeout(wu *WorkUnit, ctx context.Context, w *worker) (interface{}, error) {
+
+	// the timeout is relative to starting execution, the deadline absolute, in the pool's clock
+	c := p.clk()
+	remaining := wu.timeout
+
+	if !wu.deadline.IsZero() {
+		remaining = wu.deadline.Sub(c.Now())
+	}
+
+	// contexts compare their deadline against real time whatever the pool's clock
+	deadline := time.Now().Add(remaining)
+
+	if !wu.deadline.IsZero() && c == clock.Real {
+		deadline = wu.deadline
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	// buffered so an abandoned WorkFunc can still send it's result and exit
+	res := make(chan result, 1)
+	state := w.workerState(p)
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				res <- result{err: p.recoveryError(wu, err)}
+			}
+		}()
+
+		v, err := p.call(wu, ctx, state)
+		res <- result{value: v, err: err}
+	}()
+
+	t := c.NewTimer(remaining)
+
+	select {
+	case r := <-res:
+		t.Stop()
+
+		// the WorkFunc stopped itself due to the deadline
+		if r.err == context.DeadlineExceeded && ctx.Err() == context.DeadlineExceeded {
+			return nil, &ErrWorkTimeout{s: errTimeout}
+		}
+
+		return r.value, r.err
+	case <-t.C():
+		// the abandoned WorkFunc may still be using the worker state
+		w.discardState()
+		return nil, &ErrWorkTimeout{s: errTimeout}
+	}
+}
+
+// Queue queues the work to be run, and starts processing immediately
+func (p *Pool) Queue(fn WorkFunc) *WorkUnit {
+
+	wu := newUnit()
+	wu.fn = fn
+
+	p.push(wu, true, true)
+
+	return wu
+}
+
+// QueueMulti queues the work, which returns multiple values, to be run and starts processing
+// immediately; the values are available using the Work Unit's Values() once Done.
+func (p *Pool) QueueMulti(fn WorkFuncMulti) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:    make(chan struct{}),
+		fnMulti: fn,
+	})
+}
+
+// QueueLabeled queues the work to be run, and starts processing immediately, tagging the Work Unit
+// with the label, such as the name of the job, which is available via Label() from the tracer hook,
+// RunningUnits() and the PanicError set should the WorkFunc panic.
+func (p *Pool) QueueLabeled(fn WorkFunc, label string) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:  make(chan struct{}),
+		fn:    fn,
+		label: label,
+	})
+}
+
+// QueueWithState queues the work to be run, and starts processing immediately, passing
+// the state of the worker that runs it, see NewWithWorkerState.
+func (p *Pool) QueueWithState(fn WorkFuncState) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:    make(chan struct{}),
+		fnState: fn,
+	})
+}
+
+// QueueCancellable queues the work to be run, and starts processing immediately, passing a
+// function the WorkFunc can call to check whether the Work Unit has been cancelled since it
+// began running, allowing it to stop early.
+func (p *Pool) QueueCancellable(fn WorkFuncCancellable) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:     make(chan struct{}),
+		fnCancel: fn,
+	})
+}
+
+// QueueWithProgress queues the work to be run, and starts processing immediately, passing a
+// function the WorkFunc can call as often as it likes to report it's progress, the latest of
+// which is available from the Work Unit's Progress(), eg. for showing on a dashboard.
+func (p *Pool) QueueWithProgress(fn WorkFuncProgress) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:       make(chan struct{}),
+		fnProgress: fn,
+	})
+}
+
+// QueueCtx queues the context aware work to be run, and starts processing immediately.
+// The context, or one derived from it such as by a tracer, is passed to the WorkFunc and
+// should it already be done by the time the Work Unit is to be run the WorkFunc is not called
+// and the Work Unit's Error is set to ctx.Err().
+func (p *Pool) QueueCtx(ctx context.Context, fn WorkFuncCtx) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:  make(chan struct{}),
+		fnCtx: fn,
+		ctx:   ctx,
+	})
+}
+
+// QueueAll queues all of the work to be run, and starts processing immediately,
+// returning the Work Units in the same order as the passed WorkFuncs.
+func (p *Pool) QueueAll(fns []WorkFunc) []*WorkUnit {
+
+	units := make([]*WorkUnit, len(fns))
+
+	for i, fn := range fns {
+		units[i] = p.Queue(fn)
+	}
+
+	return units
+}
+
+// QueueWithPriority queues the work to be run, and starts processing immediately.
+// Higher priority Work Units are dispatched to free workers before lower priority ones
+// and Work Units of equal priority are dispatched in the order they were queued.
+// Work queued using Queue() has a priority of 0.
+func (p *Pool) QueueWithPriority(fn WorkFunc, priority int) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:     make(chan struct{}),
+		fn:       fn,
+		priority: priority,
+	})
+}
+
+// QueueWithTimeout queues the work to be run, and starts processing immediately.
+// The timeout starts once the Work Unit begins executing, if the WorkFunc has not returned
+// by then the Work Unit's Error is set to ErrWorkTimeout and it's Done channel closed.
+//
+// NOTE: the WorkFunc cannot be forcibly killed, it is only abandoned and may continue
+// running in the background; the worker however is released back to the pool immediately.
+func (p *Pool) QueueWithTimeout(fn WorkFunc, d time.Duration) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:    make(chan struct{}),
+		fn:      fn,
+		timeout: d,
+	})
+}
+
+// QueueCtxWithTimeout is the same as QueueWithTimeout but for context aware work, the context
+// passed to the WorkFunc carries the deadline, relative to when the Work Unit begins executing,
+// so that well behaved code such as database drivers can stop itself rather than being abandoned.
+func (p *Pool) QueueCtxWithTimeout(ctx context.Context, fn WorkFuncCtx, d time.Duration) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:    make(chan struct{}),
+		fnCtx:   fn,
+		ctx:     ctx,
+		timeout: d,
+	})
+}
+
+// QueueWithDeadline queues the context aware work to be run, and starts processing immediately.
+// Should the WorkFunc not have returned by the deadline, t, the Work Unit's Error is set to
+// ErrWorkTimeout and it's Done channel closed, as with QueueWithTimeout, and the context passed
+// to the WorkFunc carries the deadline so that it can stop itself.
+func (p *Pool) QueueWithDeadline(fn WorkFuncCtx, t time.Time) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:     make(chan struct{}),
+		fnCtx:    fn,
+		deadline: t,
+	})
+}
+
+// QueueWithRetry queues the work to be run, and starts processing immediately.
+// Should the WorkFunc return an error it will be re-executed, up to attempts times in total,
+// sleeping for the duration returned by backoff, which is passed the attempt # that just failed,
+// between each try; a nil backoff retries immediately. The Work Unit's Value and Error reflect
+// the last attempt and if the pool is cancelled in between attempts no more are made.
+func (p *Pool) QueueWithRetry(fn WorkFunc, attempts int, backoff func(attempt int) time.Duration) *WorkUnit {
+	return p.enqueue(&WorkUnit{
+		Done:    make(chan struct{}),
+		fn:      fn,
+		retries: attempts,
+		backoff: backoff,
+	})
+}
+
+// CanQueue returns a channel that is readable once there's room in the queue of a bounded pool,
+// see NewBounded and NewWithBuffer, so that producers can select on it rather than block in
+// Queue; it's readable immediately for unbounded pools, or if there's already room when called,
+// and for closed pools, as Queue then returns without blocking. Each call reflects the state at
+// the time it's made and the room may be taken by another producer before it's used, so with
+// several producers it's a hint, pair it with TryQueue if queuing must never block, eg.
+//
+//	select {
+//	case <-p.CanQueue():
+//		p.Queue(fn)
+//	case <-ctx.Done():
+//	}
+func (p *Pool) CanQueue() <-chan struct{} {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.closed || !p.full() {
+		return closedCh
+	}
+
+	if p.roomCh == nil {
+		p.roomCh = make(chan struct{})
+	}
+
+	return p.roomCh
+}
+
+// signalNotFull wakes a producer waiting for room in the queue, and any waiting on CanQueue,
+// must be called with the lock held.
+func (p *Pool) signalNotFull() {
+
+	p.notFull.Signal()
+
+	if p.roomCh != nil && !p.full() {
+		close(p.roomCh)
+		p.roomCh = nil
+	}
+}
+
+// TryQueue queues the work to be run, and starts processing immediately, unless the pool
+// is bounded and it's queue is full, see NewBounded and NewWithBuffer, in which case false is
+// returned and the work is not queued.
+func (p *Pool) TryQueue(fn WorkFunc) (*WorkUnit, bool) {
+
+	w := &WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	}
+
+	if _, full := p.push(w, false, false).(*ErrQueueFull); full {
+		return nil, false
+	}
+
+	return w, true
+}
+
+// QueueOrError queues the work to be run, and starts processing immediately, unless it can't
+// be in which case an error is returned, and no Work Unit, immediately: an ErrQueueFull error when
+// the pool is bounded and it's queue is full, see NewBounded and NewWithBuffer, or an ErrPoolClosed
+// or ErrCancelled error when the pool has been closed or cancelled.
+func (p *Pool) QueueOrError(fn WorkFunc) (*WorkUnit, error) {
+
+	w := &WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	}
+
+	if err := p.push(w, false, false); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// nextID returns the next Work Unit ID, the counter isn't reset along with the pool
+// so that IDs remain unique for the pool's lifetime.
+func (p *Pool) nextID() uint64 {
+	return atomic.AddUint64(&p.ids, 1)
+}
+
+// full reports whether the bounded queue is full or, when handing off to idle
+// workers, there are none left to take another Work Unit; must be called with the lock held.
+func (p *Pool) full() bool {
+
+	// including those queued on the workers' own queues, see QueueAffinity
+	queued := uint(len(p.queue) + p.local)
+
+	if p.handoff {
+		return queued >= p.idle
+	}
+
+	return p.maxQueued > 0 && queued >= p.maxQueued
+}
+
+func (p *Pool) enqueue(w *WorkUnit) *WorkUnit {
+	p.push(w, true, false)
+	return w
+}
+
+// push adds the Work Unit to the queue, when the pool is bounded and the queue is full it
+// waits for room or, if not blocking, returns an ErrQueueFull error without having queued the
+// Work Unit. When the pool has been closed, or there is no WorkFunc to run, the Work Unit is
+// completed with, and returns, the error. Only Work Units queued by the single producer itself,
+// using Queue(), may use the ring as the pool queues all others from goroutines of it's own,
+// see SingleProducer.
+func (p *Pool) push(w *WorkUnit, block, producer bool) error {
+
+	if w.id == 0 {
+		w.id = p.nextID()
+	}
+
+	// rather than panicking within a worker
+	if !w.hasFunc() {
+
+		err := &ErrNilWorkFunc{s: errNilFunc}
+
+		w.complete(nil, err)
+		w.settle()
+
+		return err
+	}
+
+	if p.circuitOpen(w.label) {
+
+		err := &ErrCircuitOpen{Label: w.label, s: fmt.Sprintf(errCircuitOpen, w.label)}
+
+		w.complete(nil, err)
+		w.settle()
+
+		return err
+	}
+
+	if producer && p.pushRing(w) {
+		return nil
+	}
+
+	var overflow, expired bool
+	var timer clock.Timer
+
+	p.m.Lock()
+
+	for !p.closed && !p.draining && p.full() {
+
+		if !block {
+			p.m.Unlock()
+			return &ErrQueueFull{s: errQueueFull}
+		}
+
+		// queued from within a WorkFunc, blocking could leave no worker to make room
+		if w.nested {
+			overflow = true
+			break
+		}
+
+		if expired {
+
+			d := p.queueWait
+			p.m.Unlock()
+
+			err := &ErrQueueTimeout{s: errQueueTimeout}
+
+			w.complete(nil, err)
+			w.settle()
+
+			if p.logging() {
+				p.logf("pool: Work Unit %d not queued, the queue stayed full for longer than %s", w.id, d)
+			}
+
+			return err
+		}
+
+		// woken once the queue timeout expires, as well as when there's room
+		if timer == nil && p.queueWait > 0 {
+			timer = p.clk().AfterFunc(p.queueWait, func() {
+				p.m.Lock()
+				expired = true
+				p.notFull.Broadcast()
+				p.m.Unlock()
+			})
+			defer timer.Stop()
+		}
+
+		p.notFull.Wait()
+	}
+
+	if p.closed || p.draining {
+
+		cancelled := p.cancelled
+		p.m.Unlock()
+
+		var err error = &ErrPoolClosed{s: errClosed}
+
+		if cancelled {
+			err = &ErrCancelled{s: errCancelled}
+		}
+
+		w.complete(nil, err)
+		w.settle()
+
+		return err
+	}
+
+	// cancelled before it could be queued, eg. whilst waiting on a batch's concurrency limit
+	if atomic.LoadUint32(&w.state) != stateQueued {
+		p.m.Unlock()
+		return nil
+	}
+
+	w.queued = p.now()
+	atomic.AddInt64(&p.stats.Load().queued, 1)
+
+	if w.pool == nil {
+		w.pool = p
+	}
+
+	p.stamp(w)
+
+	if p.inline {
+		p.m.Unlock()
+
+		if obs := p.observer(); obs != nil {
+			p.guard("MetricsObserver", obs.OnQueue)
+		}
+
+		p.emit(EventQueued, w.id, 0, nil)
+		p.runInline(w)
+
+		return nil
+	}
+
+	switch {
+	case w.pinned && len(p.slots) > 0:
+		p.pinKey(w)
+	case p.stealing && len(p.slots) > 0:
+		p.pushLocal(w)
+	default:
+		p.sequence(w)
+		heap.Push(&p.queue, w)
+		atomic.AddInt64(&p.stats.Load().pending, 1)
+	}
+
+	p.cond.Signal()
+	p.m.Unlock()
+
+	if overflow && p.logging() {
+		p.logf("pool: queue full, Work Unit %d queued from within a WorkFunc exceeded the bound rather than block", w.id)
+	}
+
+	if obs := p.observer(); obs != nil {
+		p.guard("MetricsObserver", obs.OnQueue)
+	}
+
+	p.emit(EventQueued, w.id, 0, nil)
+
+	return nil
+}
+
+// SetPanicHandler sets a function that is called whenever a WorkFunc panics, with the recovered
+// value and stack trace, before the panic is converted into the Work Unit's PanicError error;
+// useful for logging or metrics. It's also called should any of the other callbacks given to
+// the pool panic, eg. the MetricsObserver, tracer, slow handler or a batch's OnProgress callback,
+// which are recovered, and logged, see SetLogger, rather than taking down the goroutine calling
+// them. Should the handler itself panic it is recovered and logged. Passing nil restores the
+// default behaviour of only setting the Work Unit's error.
+func (p *Pool) SetPanicHandler(fn func(recovered interface{}, stack []byte)) {
+	p.panicHandler.Store(fn)
+}
+
+func (p *Pool) callPanicHandler(fn func(interface{}, []byte), recovered interface{}, stack []byte) {
+
+	// the handler panicking must not take down the worker
+	defer func() {
+		if r := recover(); r != nil && p.logging() {
+			p.logf("pool: panic handler panicked: '%v'", r)
+		}
+	}()
+
+	fn(recovered, stack)
+}
+
+// SetErrorClassifier sets a function that decides which of the errors returned to Work Units are
+// failures, returning false for those that aren't, eg. a sentinel error meaning there was nothing
+// to do, so that they're not counted in Stats().ErroredCount, don't cancel a batch set to
+// CancelOnError and are left out by WaitAndCollect and Wait's failures. The Work Unit's Error is
+// still set as usual. Passing nil restores the default of every error being a failure.
+func (p *Pool) SetErrorClassifier(fn func(err error) bool) {
+	p.classifier.Store(fn)
+}
+
+// failure reports whether the error is a failure, see SetErrorClassifier.
+func (p *Pool) failure(err error) (failed bool) {
+
+	if err == nil {
+		return false
+	}
+
+	fn, _ := p.classifier.Load().(func(error) bool)
+
+	if fn == nil {
+		return true
+	}
+
+	// should the classifier panic it's treated as a failure
+	failed = true
+	p.guard("error classifier", func() { failed = fn(err) })
+
+	return failed
+}
+
+// Use registers middleware that wraps every WorkFunc run on the pool, including those queued
+// through a Batch, for cross-cutting concerns like logging or metrics. Middleware wraps in the
+// order registered so the first registered is the outermost and runs first. The middleware is
+// applied around each execution, so around each attempt of a retried Work Unit.
+func (p *Pool) Use(mw Middleware) {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	// copied on write so workers can read it without locking
+	mws, _ := p.middleware.Load().([]Middleware)
+	n := make([]Middleware, len(mws), len(mws)+1)
+	copy(n, mws)
+
+	p.middleware.Store(append(n, mw))
+}
+
+// UseCtx registers context aware middleware that wraps every WorkFunc run on the pool, see Use(),
+// which is passed the Work Unit's context, see QueueCtx, or one derived from it by the tracer or
+// a timeout, allowing request scoped values such as trace IDs to be read or added. A context it
+// passes on to next flows through any regular middleware, which run inside of all context aware
+// middleware, and into a context aware WorkFunc. Work Units queued without a context pass the
+// middleware context.Background().
+func (p *Pool) UseCtx(mw MiddlewareCtx) {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	// copied on write so workers can read it without locking
+	mws, _ := p.ctxMiddleware.Load().([]MiddlewareCtx)
+	n := make([]MiddlewareCtx, len(mws), len(mws)+1)
+	copy(n, mws)
+
+	p.ctxMiddleware.Store(append(n, mw))
+}
+
+// WrapErrors sets whether the errors returned by, or panics of, WorkFuncs are annotated with the
+// Work Unit's ID and label, eg. "work unit 7 (import-users): connection refused", with the original
+// error still accessible using errors.Unwrap, errors.Is and errors.As. Disabled by default as
+// callers type asserting on the concrete error type, such as *PanicError, need to use errors.As.
+func (p *Pool) WrapErrors(enabled bool) {
+
+	var wrap uint32
+
+	if enabled {
+		wrap = 1
+	}
+
+	atomic.StoreUint32(&p.wrap, wrap)
+}
+
+// wrapError annotates the WorkFunc's error with the Work Unit's ID and label when enabled.
+func (p *Pool) wrapError(wu *WorkUnit, err error) error {
+
+	if err == nil || atomic.LoadUint32(&p.wrap) == 0 {
+		return err
+	}
+
+	if wu.label == "" {
+		return fmt.Errorf("work unit %d: %w", wu.id, err)
+	}
+
+	return fmt.Errorf("work unit %d (%s): %w", wu.id, wu.label, err)
+}
+
+// SetGoStarter sets the function used to launch each worker's goroutine, instead of a plain
+// go f(), eg. for wrapping workers with pprof.Do labels or goroutine tracking; the starter must
+// run f in a new goroutine. Only workers started afterwards, such as after Reset() or Resize(),
+// are launched using it. Passing nil restores the default.
+func (p *Pool) SetGoStarter(fn func(f func())) {
+	p.starter.Store(fn)
+}
+
+func (p *Pool) goStart(f func()) {
+
+	if start, ok := p.starter.Load().(func(func())); ok && start != nil {
+		start(f)
+		return
+	}
+
+	go f()
+}
+
+// SetTracer sets a hook that is called as each Work Unit starts, with the Work Unit's context,
+// which for work not queued using QueueCtx is context.Background(). The returned context is passed
+// on to a context aware WorkFunc, so for example a tracing span started within the hook propagates
+// into the WorkFunc, and the returned function is called with the Work Unit's error once it finishes.
+// The Work Unit's ID() can be used to correlate the span with the Work Unit's results.
+// Passing nil removes the hook, when not set there is no overhead.
+func (p *Pool) SetTracer(fn func(ctx context.Context, unit *WorkUnit) (context.Context, func(err error))) {
+	p.tracer.Store(fn)
+}
+
+// Pause stops workers from picking up any more queued Work Units, those already running
+// finish as normal. Work can still be queued whilst paused, it isn't dispatched until Resume()
+// is called. Pausing an already paused pool does nothing and a paused pool can still be
+// cancelled or closed.
+func (p *Pool) Pause() {
+	p.m.Lock()
+	p.paused = true
+	p.m.Unlock()
+}
+
+// Resume lets workers continue picking up queued Work Units after a Pause().
+func (p *Pool) Resume() {
+	p.m.Lock()
+	p.paused = false
+	p.cond.Broadcast()
+	p.m.Unlock()
+}
+
+// Resize changes the number of workers in the pool; when growing new workers are started
+// immediately and when shrinking the surplus workers exit once they've finished their current
+// Work Unit, if any. No queued work is dropped, it will just wait for the remaining workers.
+// It is safe to call concurrently with Queue and if called on a closed pool the new size
+// will be used when the pool is Reset().
+func (p *Pool) Resize(workers uint) {
+
+	if workers == 0 {
+		panic("invalid workers '0'")
+	}
+
+	// there are no workers to resize, see NewSync
+	if p.inline {
+		return
+	}
+
+	p.m.Lock()
+
+	p.workers = workers
+
+	// Reset() will start the new size
+	if p.closed {
+		p.m.Unlock()
+		return
+	}
+
+	current := uint(len(p.quits))
+
+	if workers > current {
+		p.grow(workers - current)
+	} else {
+
+		for _, quit := range p.quits[workers:] {
+			close(quit)
+		}
+
+		// the surplus workers' affinity queues are left for the remaining workers
+		for _, w := range p.slots[workers:] {
+			p.unpin(w)
+		}
+
+		p.quits = p.quits[:workers]
+		p.slots = p.slots[:workers]
+
+		// wake any idle workers so the surplus ones can exit
+		p.cond.Broadcast()
+	}
+
+	p.m.Unlock()
+
+	if workers != current && p.logging() {
+		p.logf("pool: resized from %d to %d workers", current, workers)
+	}
+}
+
+// IsClosed reports whether the pool has been closed or cancelled, by any means, and so no longer
+// runs work; Queuing work on a closed pool returns a Work Unit that is already Done with an
+// ErrPoolClosed error, or ErrCancelled error if the pool was cancelled, see IsCancelled.
+func (p *Pool) IsClosed() bool {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.closed
+}
+
+// IsCancelled reports whether the pool has been cancelled using Cancel().
+func (p *Pool) IsCancelled() bool {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.closed && p.cancelled
+}
+
+// Reset reinitializes a pool that has been closed/cancelled back to a working state.
+// if the pool has not been closed/cancelled, nothing happens as the pool is still in
+// a valid running state.
+// The pool is only reset once the Results() channels of all of it's batches have been closed,
+// otherwise an ErrBatchesOpen error is returned and the pool left closed, see ResetForce.
+func (p *Pool) Reset() error {
+	return p.reset(false)
+}
+
+// ResetForce is the same as Reset() except that any of the pool's batches that still have
+// results to be read are abandoned, their remaining results are discarded and their Results()
+// channels closed, rather than the reset failing.
+func (p *Pool) ResetForce() {
+	_ = p.reset(true)
+}
+
+func (p *Pool) reset(force bool) error {
+
+	p.m.Lock()
+
+	if !p.closed {
+		p.m.Unlock()
+		return nil
+	}
+
+	var abandoned []*Batch
+
+	if len(p.batches) > 0 {
+
+		if !force {
+			p.m.Unlock()
+			return &ErrBatchesOpen{s: errBatches}
+		}
+
+		for b := range p.batches {
+			abandoned = append(abandoned, b)
+		}
+
+		p.batches = nil
+	}
+
+	// cancelled the pool, not closed it, pool will be usable after calling initialize().
+	p.initialize()
+	p.m.Unlock()
+
+	// batches lock themselves before the pool, so can't be abandoned whilst holding the lock
+	for _, b := range abandoned {
+		b.abandon()
+	}
+
+	if p.parent != nil {
+		p.parent.adopt(p)
+	}
+
+	return nil
+}
+
+func (p *Pool) closeWithError(err error) {
+	p.m.Lock()
+	children := p.closeLocked(err)
+	p.m.Unlock()
+	p.cascade(children, err)
+}
+
+// closeLocked closes the pool, must be called with the lock held, returning the child
+// pools which are to be closed along with it once the lock has been released, see cascade.
+func (p *Pool) closeLocked(err error) []*Pool {
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	if !p.closed {
+		close(p.cancel)
+		p.closed = true
+		_, p.cancelled = err.(*ErrCancelled)
+		atomic.StoreUint32(&p.open, 0)
+		atomic.StoreUint32(&p.drainStat, 0)
+	}
+
+	p.emptyRing(err)
+
+	for _, wu := range p.queue {
+		if wu.cancelWithError(err) {
+			wu.settle()
+		}
+	}
+
+	atomic.AddInt64(&p.stats.Load().pending, -int64(len(p.queue)))
+	p.queue = nil
+
+	for _, w := range p.slots {
+		for _, wu := range w.local {
+			wu.cancelWithError(err)
+			wu.settle()
+		}
+
+		atomic.AddInt64(&p.stats.Load().pending, -int64(len(w.local)))
+		w.local = nil
+	}
+
+	p.local = 0
+
+	// wake all idle workers so they exit and any blocked producers
+	p.cond.Broadcast()
+	p.notFull.Broadcast()
+	p.checkDrained()
+
+	// Queue no longer blocks once closed
+	if p.roomCh != nil {
+		close(p.roomCh)
+		p.roomCh = nil
+	}
+
+	return p.unlinkChildren()
+}
+
+// abandonActive closes the Done channels of all running Work Units with the error,
+// their results are discarded when they eventually finish, must be called with the lock held.
+func (p *Pool) abandonActive(err error) {
+	for wu := range p.active {
+		wu.complete(nil, err)
+		delete(p.active, wu)
+	}
+}
+
+// Cancel cleans up the pool workers and channels and cancels and pending
+// work still yet to be processed.
+// call Reset() to reinitialize the pool for use.
+func (p *Pool) Cancel() {
+
+	err := &ErrCancelled{s: errCancelled}
+	p.closeWithError(err)
+}
+
+// Close cleans up the pool workers and channels and cancels any pending
+// work still yet to be processed.
+// call Reset() to reinitialize the pool for use.
+func (p *Pool) Close() {
+
+	err := &ErrPoolClosed{s: errClosed}
+	p.closeWithError(err)
+}
+
+// Shutdown stops the pool from accepting any new work, which will receive an ErrPoolClosed error,
+// and waits for all queued and running Work Units to finish before closing the pool.
+// If the context expires first the pool is closed immediately and all remaining Work Units,
+// including those still running which are abandoned, have their Done channels closed with
+// an ErrPoolClosed error and ctx.Err() is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+
+	drained := p.drain()
+
+	if drained == nil {
+		return nil
+	}
+
+	select {
+	case <-drained:
+		p.Close()
+		return nil
+	case <-ctx.Done():
+	}
+
+	err := &ErrPoolClosed{s: errClosed}
+
+	p.m.Lock()
+	p.abandonActive(err)
+	children := p.closeLocked(err)
+	p.m.Unlock()
+	p.cascade(children, err)
+	<-drained
+
+	return ctx.Err()
+}
+
+// Drain stops the pool from accepting any new work, which will receive an ErrPoolClosed error,
+// and lets all queued and running Work Units run to completion before the pool is closed,
+// without waiting; useful for rolling restarts. See DrainAndWait and Shutdown to wait for it.
+func (p *Pool) Drain() {
+
+	drained := p.drain()
+
+	if drained == nil {
+		return
+	}
+
+	go func() {
+		<-drained
+		p.Close()
+	}()
+}
+
+// DrainAndWait is the same as Drain() but blocks until all queued and running
+// Work Units have completed and the pool has been closed.
+func (p *Pool) DrainAndWait() {
+	_ = p.Shutdown(context.Background())
+}
+
+// drain stops the pool from accepting new work returning a channel that is closed once nothing
+// is queued or running, or nil if the pool has already been closed.
+func (p *Pool) drain() <-chan struct{} {
+
+	p.m.Lock()
+
+	if p.closed {
+		p.m.Unlock()
+		return nil
+	}
+
+	p.draining = true
+	atomic.StoreUint32(&p.drainStat, 1)
+
+	// so that the ring stops accepting work too, see pushRing
+	atomic.StoreUint32(&p.open, 0)
+	p.m.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		p.m.Lock()
+		for !p.isIdle() {
+			p.drained.Wait()
+		}
+		p.m.Unlock()
+		close(drained)
+	}()
+
+	return drained
 }
@@ -1,8 +1,13 @@
 package pool
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -189,3 +194,1688 @@ func TestPanicRecovery(t *testing.T) {
 func TestBadWorkerCount(t *testing.T) {
 	PanicMatches(t, func() { New(0) }, "invalid workers '0'")
 }
+
+func TestQueueWithTimeout(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	newFunc := func(d time.Duration) WorkFunc {
+		return func() (interface{}, error) {
+			time.Sleep(d)
+			return 1, nil
+		}
+	}
+
+	wu := pool.QueueWithTimeout(newFunc(time.Second*1), time.Millisecond*100)
+	<-wu.Done
+
+	NotEqual(t, wu.Error, nil)
+	_, ok := wu.Error.(*ErrWorkTimeout)
+	Equal(t, ok, true)
+	Equal(t, wu.Error.Error(), "ERROR: Work Unit timed out before completing")
+	Equal(t, wu.Value, nil)
+
+	// the single worker must have been released despite the abandoned WorkFunc still running
+	start := time.Now()
+	wu = pool.QueueWithTimeout(newFunc(time.Millisecond*10), time.Second*1)
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 1)
+	Equal(t, time.Since(start) < time.Millisecond*500, true)
+}
+
+func TestResize(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 50)
+		return 1, nil
+	}
+
+	start := time.Now()
+
+	for i := 0; i < 64; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	// with 4 workers this run would take ~800ms
+	time.Sleep(time.Millisecond * 75)
+	pool.Resize(16)
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+	}
+
+	Equal(t, time.Since(start) < time.Millisecond*500, true)
+
+	// shrinking must not drop any of the queued work
+	res = res[:0]
+
+	for i := 0; i < 16; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	pool.Resize(1)
+
+	var count int
+
+	for _, wu := range res {
+		<-wu.Done
+		count += wu.Value.(int)
+	}
+
+	Equal(t, count, 16)
+	PanicMatches(t, func() { pool.Resize(0) }, "invalid workers '0'")
+}
+
+func TestQueueWithRetry(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var calls int32
+
+	backoff := func(attempt int) time.Duration {
+		return time.Millisecond * 10 * time.Duration(attempt)
+	}
+
+	wu := pool.QueueWithRetry(func() (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return nil, errors.New("not yet")
+		}
+		return 1, nil
+	}, 5, backoff)
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Attempts(), 3)
+
+	wu = pool.QueueWithRetry(func() (interface{}, error) {
+		return nil, errors.New("always bad")
+	}, 3, nil)
+	<-wu.Done
+
+	NotEqual(t, wu.Error, nil)
+	Equal(t, wu.Error.Error(), "always bad")
+	Equal(t, wu.Attempts(), 3)
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	Equal(t, wu.Attempts(), 1)
+
+	// cancelling the pool during the backoff stops any further attempts
+	wu = pool.QueueWithRetry(func() (interface{}, error) {
+		return nil, errors.New("bad")
+	}, 5, func(int) time.Duration { return time.Second * 10 })
+
+	time.Sleep(time.Millisecond * 100)
+	pool.Cancel()
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+	Equal(t, wu.Attempts(), 1)
+}
+
+func TestQueueWithPriority(t *testing.T) {
+
+	var order []string
+	m := new(sync.Mutex)
+
+	pool := New(1)
+	defer pool.Close()
+
+	newFunc := func(name string) WorkFunc {
+		return func() (interface{}, error) {
+			m.Lock()
+			order = append(order, name)
+			m.Unlock()
+			return nil, nil
+		}
+	}
+
+	// keep the only worker busy so the rest of the units queue up
+	blocker := pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 100)
+		return nil, nil
+	})
+
+	time.Sleep(time.Millisecond * 20)
+
+	res := []*WorkUnit{
+		pool.Queue(newFunc("low1")),
+		pool.QueueWithPriority(newFunc("high1"), 10),
+		pool.QueueWithPriority(newFunc("mid"), 5),
+		pool.Queue(newFunc("low2")),
+		pool.QueueWithPriority(newFunc("high2"), 10),
+		pool.QueueWithPriority(newFunc("neg"), -1),
+	}
+
+	<-blocker.Done
+
+	for _, wu := range res {
+		<-wu.Done
+	}
+
+	Equal(t, order, []string{"high1", "high2", "mid", "low1", "low2", "neg"})
+}
+
+func TestBoundedQueue(t *testing.T) {
+
+	pool := NewBounded(1, 2)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	blocker := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	// wait for the worker to pick up the blocker so the queue is empty
+	for pool.Stats().RunningCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	res := []*WorkUnit{pool.Queue(fn), pool.Queue(fn)}
+
+	wu, ok := pool.TryQueue(fn)
+	Equal(t, ok, false)
+	Equal(t, wu == nil, true)
+
+	queued := make(chan *WorkUnit)
+
+	go func() {
+		queued <- pool.Queue(fn)
+	}()
+
+	select {
+	case <-queued:
+		t.Fatal("Queue should have blocked on a full bounded queue")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(release)
+	<-blocker.Done
+
+	res = append(res, <-queued)
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Value, 1)
+	}
+
+	wu, ok = pool.TryQueue(fn)
+	Equal(t, ok, true)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	PanicMatches(t, func() { NewBounded(1, 0) }, "invalid maxQueued '0'")
+}
+
+func TestBoundedQueueClose(t *testing.T) {
+
+	pool := NewBounded(1, 1)
+	defer pool.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	pool.Queue(fn)
+	pool.Queue(fn)
+
+	queued := make(chan *WorkUnit)
+
+	go func() {
+		queued <- pool.Queue(fn)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	pool.Close()
+
+	// blocked producers are released with an error once the pool closes
+	wu := <-queued
+	<-wu.Done
+	_, ok := wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+}
+
+func TestCanQueue(t *testing.T) {
+
+	unbounded := New(1)
+	defer unbounded.Close()
+
+	select {
+	case <-unbounded.CanQueue():
+	default:
+		t.Fatal("CanQueue should always be readable for an unbounded pool")
+	}
+
+	pool := NewBounded(1, 1)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	blocker := pool.Queue(fn)
+
+	for pool.Stats().RunningCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case <-pool.CanQueue():
+	default:
+		t.Fatal("CanQueue should be readable whilst there's room")
+	}
+
+	queued := pool.Queue(fn)
+
+	// at capacity
+	ch := pool.CanQueue()
+
+	select {
+	case <-ch:
+		t.Fatal("CanQueue should not be readable whilst the queue is full")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(release)
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("CanQueue should be readable once there's room")
+	}
+
+	<-blocker.Done
+	<-queued.Done
+
+	pool.Close()
+
+	select {
+	case <-pool.CanQueue():
+	default:
+		t.Fatal("CanQueue should be readable once closed")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 50)
+		return 1, nil
+	}
+
+	for i := 0; i < 8; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	Equal(t, pool.Shutdown(ctx), nil)
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+		Equal(t, wu.Value, 1)
+	}
+
+	wu := pool.Queue(fn)
+	<-wu.Done
+	_, ok := wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	// shutting down an already closed pool is a no-op
+	Equal(t, pool.Shutdown(ctx), nil)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(2)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		time.Sleep(time.Second * 1)
+		return 1, nil
+	}
+
+	for i := 0; i < 4; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	start := time.Now()
+
+	Equal(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+
+	for _, wu := range res {
+		<-wu.Done
+		_, ok := wu.Error.(*ErrPoolClosed)
+		Equal(t, ok, true)
+	}
+
+	Equal(t, time.Since(start) < time.Millisecond*500, true)
+}
+
+func TestWaitAll(t *testing.T) {
+
+	pool := New(3)
+	defer pool.Close()
+
+	newFunc := func(d time.Duration, err error) WorkFunc {
+		return func() (interface{}, error) {
+			time.Sleep(d)
+			return nil, err
+		}
+	}
+
+	bad := errors.New("bad fetch")
+
+	a := pool.Queue(newFunc(time.Millisecond*100, nil))
+	b := pool.Queue(newFunc(time.Millisecond*10, bad))
+	c := pool.Queue(newFunc(time.Millisecond*50, nil))
+
+	errs := WaitAll(a, b, c)
+
+	Equal(t, len(errs), 3)
+	Equal(t, errs[0], nil)
+	Equal(t, errs[1], bad)
+	Equal(t, errs[2], nil)
+
+	Equal(t, len(WaitAll()), 0)
+}
+
+func TestSetPanicHandler(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var recovered interface{}
+	var stack []byte
+
+	pool.SetPanicHandler(func(r interface{}, s []byte) {
+		recovered = r
+		stack = s
+	})
+
+	wu := pool.Queue(func() (interface{}, error) {
+		panic("bad things")
+	})
+	<-wu.Done
+
+	Equal(t, recovered, "bad things")
+	Equal(t, len(stack) > 0, true)
+	_, ok := wu.Error.(*ErrRecovery)
+	Equal(t, ok, true)
+
+	// also called for timeout units which run in their own goroutine
+	recovered = nil
+
+	wu = pool.QueueWithTimeout(func() (interface{}, error) {
+		panic("bad timeout things")
+	}, time.Second)
+	<-wu.Done
+
+	Equal(t, recovered, "bad timeout things")
+
+	// a panicking handler must not take down the worker
+	pool.SetPanicHandler(func(r interface{}, s []byte) {
+		panic("bad handler")
+	})
+
+	wu = pool.Queue(func() (interface{}, error) {
+		panic("bad things")
+	})
+	<-wu.Done
+
+	_, ok = wu.Error.(*ErrRecovery)
+	Equal(t, ok, true)
+
+	pool.SetPanicHandler(nil)
+
+	wu = pool.Queue(func() (interface{}, error) {
+		return 1, nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+}
+
+func TestPanicError(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	wu := pool.Queue(func() (interface{}, error) {
+		panic("OMG")
+	})
+	<-wu.Done
+
+	var pe *PanicError
+	Equal(t, errors.As(wu.Error, &pe), true)
+	Equal(t, pe.Value, "OMG")
+	Equal(t, len(pe.Stack) > 0, true)
+	Equal(t, pe.Unwrap(), nil)
+
+	// still compatible with those checking for ErrRecovery
+	_, ok := wu.Error.(*ErrRecovery)
+	Equal(t, ok, true)
+
+	bad := errors.New("bad")
+
+	wu = pool.Queue(func() (interface{}, error) {
+		panic(bad)
+	})
+	<-wu.Done
+
+	Equal(t, errors.Is(wu.Error, bad), true)
+
+	// a returned error is not a panic
+	wu = pool.Queue(func() (interface{}, error) {
+		return nil, bad
+	})
+	<-wu.Done
+
+	Equal(t, errors.As(wu.Error, &pe), false)
+}
+
+func TestQueueAll(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	newFunc := func(i int) WorkFunc {
+		return func() (interface{}, error) {
+			return i, nil
+		}
+	}
+
+	var fns []WorkFunc
+
+	for i := 0; i < 10; i++ {
+		fns = append(fns, newFunc(i))
+	}
+
+	units := pool.QueueAll(fns)
+	Equal(t, len(units), 10)
+
+	for i, wu := range units {
+		<-wu.Done
+		Equal(t, wu.Value, i)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+
+	var res []*WorkUnit
+	var executed int32
+
+	pool := New(4)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&executed, 1)
+		return 1, nil
+	}
+
+	pool.Pause()
+	pool.Pause()
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	Equal(t, atomic.LoadInt32(&executed), int32(0))
+
+	pool.Resume()
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Value, 1)
+	}
+
+	Equal(t, atomic.LoadInt32(&executed), int32(10))
+
+	// a paused pool can still be cancelled
+	pool.Pause()
+	wu := pool.Queue(fn)
+	pool.Cancel()
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+}
+
+func TestQueueCtx(t *testing.T) {
+
+	type key struct{}
+
+	pool := New(2)
+	defer pool.Close()
+
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	wu := pool.QueueCtx(ctx, func(ctx context.Context) (interface{}, error) {
+		return ctx.Value(key{}), nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, "value")
+
+	// a context that is done before the Work Unit starts means it's never run
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var called bool
+
+	wu = pool.QueueCtx(ctx, func(ctx context.Context) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Error, context.Canceled)
+	Equal(t, called, false)
+}
+
+func TestSetTracer(t *testing.T) {
+
+	type spanKey struct{}
+
+	pool := New(2)
+	defer pool.Close()
+
+	var m sync.Mutex
+	var started int
+	var ended []error
+
+	pool.SetTracer(func(ctx context.Context, unit *WorkUnit) (context.Context, func(error)) {
+		m.Lock()
+		started++
+		m.Unlock()
+
+		return context.WithValue(ctx, spanKey{}, "span"), func(err error) {
+			m.Lock()
+			ended = append(ended, err)
+			m.Unlock()
+		}
+	})
+
+	bad := errors.New("bad")
+
+	wu := pool.QueueCtx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return ctx.Value(spanKey{}), bad
+	})
+	<-wu.Done
+
+	Equal(t, wu.Value, "span")
+
+	wu = pool.Queue(func() (interface{}, error) {
+		return 1, nil
+	})
+	<-wu.Done
+
+	m.Lock()
+	Equal(t, started, 2)
+	Equal(t, ended, []error{bad, nil})
+	m.Unlock()
+
+	pool.SetTracer(nil)
+
+	wu = pool.Queue(func() (interface{}, error) {
+		return 1, nil
+	})
+	<-wu.Done
+
+	m.Lock()
+	Equal(t, started, 2)
+	m.Unlock()
+}
+
+func TestUse(t *testing.T) {
+
+	var order []string
+	m := new(sync.Mutex)
+
+	record := func(s string) {
+		m.Lock()
+		order = append(order, s)
+		m.Unlock()
+	}
+
+	newMiddleware := func(name string) Middleware {
+		return func(next WorkFunc) WorkFunc {
+			return func() (interface{}, error) {
+				record(name + " before")
+				v, err := next()
+				record(name + " after")
+				return v, err
+			}
+		}
+	}
+
+	pool := New(2)
+	defer pool.Close()
+
+	pool.Use(newMiddleware("first"))
+	pool.Use(newMiddleware("second"))
+
+	wu := pool.Queue(func() (interface{}, error) {
+		record("work")
+		return 1, nil
+	})
+	<-wu.Done
+
+	expected := []string{"first before", "second before", "work", "second after", "first after"}
+
+	Equal(t, wu.Value, 1)
+	Equal(t, order, expected)
+
+	// batches and context aware work go through the same middleware
+	order = nil
+
+	batch := pool.Batch()
+	batch.Queue(func() (interface{}, error) {
+		record("work")
+		return 1, nil
+	})
+	batch.QueueComplete()
+
+	for range batch.Results() {
+	}
+
+	Equal(t, order, expected)
+
+	order = nil
+
+	wu = pool.QueueCtx(context.Background(), func(ctx context.Context) (interface{}, error) {
+		record("work")
+		return 1, nil
+	})
+	<-wu.Done
+
+	Equal(t, order, expected)
+}
+
+func TestWorkUnitID(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) { return nil, nil }
+
+	ids := make(map[uint64]struct{})
+	var last uint64
+
+	for i := 0; i < 10; i++ {
+		wu := pool.Queue(fn)
+		NotEqual(t, wu.ID(), uint64(0))
+		Equal(t, wu.ID() > last, true)
+		last = wu.ID()
+		ids[wu.ID()] = struct{}{}
+	}
+
+	// IDs carry on from where they were after a reset
+	pool.Reset()
+
+	for i := 0; i < 10; i++ {
+		wu := pool.Queue(fn)
+		<-wu.Done
+		ids[wu.ID()] = struct{}{}
+	}
+
+	Equal(t, len(ids), 20)
+
+	wu := pool.Queue(func() (interface{}, error) {
+		panic("OMG")
+	})
+	<-wu.Done
+
+	var pe *PanicError
+
+	Equal(t, errors.As(wu.Error, &pe), true)
+	Equal(t, pe.UnitID, wu.ID())
+}
+
+func TestCancelQueued(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var executed int32
+	block := make(chan struct{})
+
+	var units []*WorkUnit
+
+	for i := 0; i < 10; i++ {
+		units = append(units, pool.Queue(func() (interface{}, error) {
+			atomic.AddInt32(&executed, 1)
+			<-block
+			return nil, nil
+		}))
+	}
+
+	for pool.Pending() != 8 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// cancel the still queued tail
+	for _, wu := range units[2:] {
+		wu.Cancel()
+	}
+
+	Equal(t, pool.Pending(), 0)
+
+	for _, wu := range units[2:] {
+		<-wu.Done
+		_, ok := wu.Error.(*ErrCancelled)
+		Equal(t, ok, true)
+	}
+
+	close(block)
+	WaitAll(units[:2]...)
+
+	Equal(t, atomic.LoadInt32(&executed), int32(2))
+
+	// cancelling a running Work Unit is seen by a WorkFuncCancellable
+	started := make(chan struct{})
+
+	wu := pool.QueueCancellable(func(cancelled func() bool) (interface{}, error) {
+
+		close(started)
+
+		for !cancelled() {
+			time.Sleep(time.Millisecond)
+		}
+
+		return nil, errors.New("stopped early")
+	})
+
+	<-started
+	wu.Cancel()
+	<-wu.Done
+
+	Equal(t, wu.Error.Error(), "stopped early")
+}
+
+func TestIsDone(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	block := make(chan struct{})
+
+	wu := pool.Queue(func() (interface{}, error) {
+		<-block
+		return 1, nil
+	})
+
+	Equal(t, wu.IsDone(), false)
+
+	close(block)
+
+	for !wu.IsDone() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// safe to read without waiting on Done
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Error, nil)
+
+	// cancelled whilst queued behind a running Work Unit
+	block = make(chan struct{})
+	running := pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	wu.Cancel()
+
+	Equal(t, wu.IsDone(), true)
+
+	close(block)
+	<-running.Done
+}
+
+func TestNewWithDeadline(t *testing.T) {
+
+	pool := NewWithDeadline(1, time.Millisecond*100)
+	defer pool.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	running := pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	queued := pool.Queue(func() (interface{}, error) { return nil, nil })
+
+	<-running.Done
+	<-queued.Done
+
+	_, ok := running.Error.(*ErrDeadlineExceeded)
+	Equal(t, ok, true)
+
+	_, ok = queued.Error.(*ErrDeadlineExceeded)
+	Equal(t, ok, true)
+	Equal(t, queued.Error.Error(), "ERROR: Work Unit cancelled as the pool's deadline was exceeded")
+
+	wu := pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	// the deadline restarts on reset
+	pool.Reset()
+
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 1)
+
+	wu = pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+	<-wu.Done
+
+	_, ok = wu.Error.(*ErrDeadlineExceeded)
+	Equal(t, ok, true)
+
+	PanicMatches(t, func() { NewWithDeadline(1, 0) }, "invalid deadline '0s'")
+}
+
+func TestQueueLabeled(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var traced string
+
+	pool.SetTracer(func(ctx context.Context, unit *WorkUnit) (context.Context, func(err error)) {
+		traced = unit.Label()
+		return ctx, nil
+	})
+
+	release := make(chan struct{})
+
+	wu := pool.QueueLabeled(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}, "import-users")
+
+	for len(pool.RunningUnits()) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, pool.RunningUnits()[0].Label(), "import-users")
+
+	close(release)
+	<-wu.Done
+
+	Equal(t, traced, "import-users")
+
+	wu = pool.QueueLabeled(func() (interface{}, error) {
+		panic("OMG")
+	}, "export-users")
+	<-wu.Done
+
+	var pe *PanicError
+
+	Equal(t, errors.As(wu.Error, &pe), true)
+	Equal(t, pe.Label, "export-users")
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	Equal(t, wu.Label(), "")
+}
+
+func TestDrain(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 6; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	pool.Drain()
+
+	Equal(t, pool.Stats().Draining, true)
+
+	wu := pool.Queue(fn)
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	close(release)
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+	}
+
+	for pool.Stats().Draining {
+		time.Sleep(time.Millisecond)
+	}
+
+	// closed once drained
+	wu = pool.Queue(fn)
+	<-wu.Done
+
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	pool.Reset()
+
+	var count int32
+
+	for i := 0; i < 6; i++ {
+		pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond * 10)
+			atomic.AddInt32(&count, 1)
+			return nil, nil
+		})
+	}
+
+	pool.DrainAndWait()
+
+	Equal(t, atomic.LoadInt32(&count), int32(6))
+	Equal(t, pool.Stats().Draining, false)
+}
+
+func TestNewWithBuffer(t *testing.T) {
+
+	pool := NewWithBuffer(2, 0)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	// handed directly to the idle workers
+	for i := 0; i < 2; i++ {
+		pool.Queue(fn)
+	}
+
+	_, ok := pool.TryQueue(fn)
+	Equal(t, ok, false)
+
+	close(release)
+
+	wu := pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+
+	buffered := NewWithBuffer(1, 2)
+	defer buffered.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	buffered.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	for len(buffered.RunningUnits()) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, ok = buffered.TryQueue(fn)
+		Equal(t, ok, true)
+	}
+
+	_, ok = buffered.TryQueue(fn)
+	Equal(t, ok, false)
+}
+
+func TestIsClosedIsCancelled(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	fn := func() (interface{}, error) { return nil, nil }
+
+	Equal(t, pool.IsClosed(), false)
+	Equal(t, pool.IsCancelled(), false)
+
+	pool.Close()
+
+	Equal(t, pool.IsClosed(), true)
+	Equal(t, pool.IsCancelled(), false)
+
+	wu := pool.Queue(fn)
+	Equal(t, wu.IsDone(), true)
+
+	_, ok := wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	pool.Reset()
+
+	Equal(t, pool.IsClosed(), false)
+
+	pool.Cancel()
+
+	Equal(t, pool.IsClosed(), true)
+	Equal(t, pool.IsCancelled(), true)
+
+	wu = pool.Queue(fn)
+	Equal(t, wu.IsDone(), true)
+
+	_, ok = wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	pool.Reset()
+
+	Equal(t, pool.IsClosed(), false)
+	Equal(t, pool.IsCancelled(), false)
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+}
+
+func TestQueueMulti(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	wu := pool.QueueMulti(func() ([]interface{}, error) {
+		return []interface{}{"id", 42}, nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Values(), []interface{}{"id", 42})
+
+	wu = pool.QueueMulti(func() ([]interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	<-wu.Done
+
+	Equal(t, wu.Error.Error(), "failed")
+	Equal(t, len(wu.Values()), 0)
+	Equal(t, wu.Value, nil)
+
+	// single value Work Units are unchanged
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Values(), []interface{}{1})
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	Equal(t, len(wu.Values()), 0)
+}
+
+func TestWrapErrors(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	errFailed := errors.New("failed")
+
+	fn := func() (interface{}, error) { return nil, errFailed }
+
+	wu := pool.Queue(fn)
+	<-wu.Done
+
+	Equal(t, wu.Error, errFailed)
+
+	pool.WrapErrors(true)
+
+	wu = pool.QueueLabeled(fn, "import-users")
+	<-wu.Done
+
+	Equal(t, wu.Error.Error(), fmt.Sprintf("work unit %d (import-users): failed", wu.ID()))
+	Equal(t, errors.Unwrap(wu.Error), errFailed)
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+
+	Equal(t, wu.Error.Error(), fmt.Sprintf("work unit %d: failed", wu.ID()))
+
+	wu = pool.Queue(func() (interface{}, error) { panic("OMG") })
+	<-wu.Done
+
+	var pe *PanicError
+
+	Equal(t, errors.As(wu.Error, &pe), true)
+	Equal(t, pe.UnitID, wu.ID())
+
+	// successful Work Units are untouched
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+
+	pool.WrapErrors(false)
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+
+	Equal(t, wu.Error, errFailed)
+}
+
+func TestQueueWithDeadline(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	deadline := time.Now().Add(time.Millisecond * 50)
+
+	var got time.Time
+
+	wu := pool.QueueWithDeadline(func(ctx context.Context) (interface{}, error) {
+
+		got, _ = ctx.Deadline()
+
+		// cooperative, stops once the context is done
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	}, deadline)
+	<-wu.Done
+
+	Equal(t, got.Equal(deadline), true)
+
+	_, ok := wu.Error.(*ErrWorkTimeout)
+	Equal(t, ok, true)
+
+	wu = pool.QueueWithDeadline(func(ctx context.Context) (interface{}, error) {
+		return 1, nil
+	}, time.Now().Add(time.Second))
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 1)
+
+	// relative to when execution starts
+	block := make(chan struct{})
+
+	running := pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	var remaining time.Duration
+
+	wu = pool.QueueCtxWithTimeout(context.Background(), func(ctx context.Context) (interface{}, error) {
+		d, _ := ctx.Deadline()
+		remaining = time.Until(d)
+		return nil, nil
+	}, time.Millisecond*100)
+
+	time.Sleep(time.Millisecond * 150)
+	close(block)
+
+	<-running.Done
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, remaining > time.Millisecond*50, true)
+}
+
+func TestSetGoStarter(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var started int32
+
+	pool.SetGoStarter(func(f func()) {
+		atomic.AddInt32(&started, 1)
+		go f()
+	})
+
+	pool.Cancel()
+	pool.Reset()
+
+	Equal(t, atomic.LoadInt32(&started), int32(2))
+
+	pool.Resize(3)
+
+	Equal(t, atomic.LoadInt32(&started), int32(3))
+
+	// a worker replaced after a panic
+	wu := pool.Queue(func() (interface{}, error) { panic("OMG") })
+	<-wu.Done
+
+	for atomic.LoadInt32(&started) != 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+
+	pool.SetGoStarter(nil)
+	pool.Cancel()
+	pool.Reset()
+
+	Equal(t, atomic.LoadInt32(&started), int32(4))
+}
+
+func TestSetQueueTimeout(t *testing.T) {
+
+	pool := NewBounded(1, 1)
+	defer pool.Close()
+
+	pool.SetQueueTimeout(time.Millisecond * 20)
+
+	block := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-block
+		return nil, nil
+	}
+
+	running := pool.Queue(fn)
+
+	for len(pool.RunningUnits()) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	queued := pool.Queue(fn)
+
+	start := time.Now()
+	wu := pool.Queue(fn)
+
+	Equal(t, wu.IsDone(), true)
+	Equal(t, time.Since(start) >= time.Millisecond*20, true)
+
+	_, ok := wu.Error.(*ErrQueueTimeout)
+	Equal(t, ok, true)
+	Equal(t, wu.Error.Error(), "ERROR: Work Unit not queued as the pool's queue stayed full for longer than the queue timeout")
+	Equal(t, pool.Pending(), 1)
+
+	// waits forever, until there's room
+	pool.SetQueueTimeout(0)
+
+	done := make(chan *WorkUnit)
+
+	go func() {
+		done <- pool.Queue(func() (interface{}, error) { return nil, nil })
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Queue did not block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(block)
+	WaitAll(running, queued)
+
+	wu = <-done
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+
+	PanicMatches(t, func() { pool.SetQueueTimeout(-time.Second) }, "invalid timeout '-1s'")
+}
+
+func TestQueueOrError(t *testing.T) {
+
+	pool := NewBounded(1, 1)
+	defer pool.Close()
+
+	block := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-block
+		return nil, nil
+	}
+
+	running, err := pool.QueueOrError(fn)
+	Equal(t, err, nil)
+
+	for len(pool.RunningUnits()) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	queued, err := pool.QueueOrError(fn)
+	Equal(t, err, nil)
+
+	wu, err := pool.QueueOrError(fn)
+	Equal(t, wu == nil, true)
+
+	_, ok := err.(*ErrQueueFull)
+	Equal(t, ok, true)
+	Equal(t, err.Error(), "ERROR: Work Unit not queued as the pool's queue is full")
+
+	close(block)
+	WaitAll(running, queued)
+
+	pool.Close()
+
+	_, err = pool.QueueOrError(fn)
+
+	_, ok = err.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	pool.Reset()
+	pool.Cancel()
+
+	_, err = pool.QueueOrError(fn)
+
+	_, ok = err.(*ErrCancelled)
+	Equal(t, ok, true)
+}
+
+type ctxKey string
+
+func TestUseCtx(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var order []string
+
+	pool.UseCtx(func(next WorkFuncCtx) WorkFuncCtx {
+		return func(ctx context.Context) (interface{}, error) {
+			order = append(order, fmt.Sprint("ctx:", ctx.Value(ctxKey("trace"))))
+			return next(context.WithValue(ctx, ctxKey("user"), "joeybloggs"))
+		}
+	})
+
+	pool.Use(func(next WorkFunc) WorkFunc {
+		return func() (interface{}, error) {
+			order = append(order, "plain")
+			return next()
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("trace"), "abc")
+
+	wu := pool.QueueCtx(ctx, func(ctx context.Context) (interface{}, error) {
+		return ctx.Value(ctxKey("trace")).(string) + ":" + ctx.Value(ctxKey("user")).(string), nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, "abc:joeybloggs")
+	Equal(t, order, []string{"ctx:abc", "plain"})
+	Equal(t, wu.Context(), ctx)
+
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Context(), context.Background())
+	Equal(t, order[2], "ctx:<nil>")
+}
+
+func TestQueueNilWorkFunc(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	var wf WorkFunc
+
+	isNil := func(wu *WorkUnit) {
+		<-wu.Done
+		Equal(t, wu.Value, nil)
+		_, ok := wu.Error.(*ErrNilWorkFunc)
+		Equal(t, ok, true)
+	}
+
+	isNil(pool.Queue(nil))
+	isNil(pool.QueueMulti(nil))
+	isNil(pool.QueueLabeled(nil, "label"))
+	isNil(pool.QueueWithState(nil))
+	isNil(pool.QueueCancellable(nil))
+	isNil(pool.QueueCtx(context.Background(), nil))
+	isNil(pool.QueueWithPriority(nil, 1))
+	isNil(pool.QueueWithTimeout(nil, time.Second))
+	isNil(pool.QueueCtxWithTimeout(context.Background(), nil, time.Second))
+	isNil(pool.QueueWithDeadline(nil, time.Now().Add(time.Second)))
+	isNil(pool.QueueWithRetry(nil, 3, nil))
+	isNil(pool.QueueDedup("key", nil))
+	isNil(pool.Submit(nil).Unit())
+
+	for _, wu := range pool.QueueAll([]WorkFunc{wf, nil}) {
+		isNil(wu)
+	}
+
+	wu, ok := pool.TryQueue(nil)
+	Equal(t, ok, true)
+	isNil(wu)
+
+	wu, err := pool.QueueOrError(nil)
+	Equal(t, wu == nil, true)
+	_, ok = err.(*ErrNilWorkFunc)
+	Equal(t, ok, true)
+
+	wu, err = pool.QueueWithCost(nil, 0)
+	Equal(t, wu == nil, true)
+	_, ok = err.(*ErrNilWorkFunc)
+	Equal(t, ok, true)
+
+	isNil(pool.QueueStream(nil))
+	isNil(pool.QueueSpawn(nil))
+
+	// the only worker is still alive
+	wu = pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+	Equal(t, pool.Stats().ErroredCount, int64(0))
+}
+
+func TestQueueFromWorkFunc(t *testing.T) {
+
+	for _, pool := range []*Pool{NewBounded(1, 1), NewWithBuffer(1, 0)} {
+
+		var wg sync.WaitGroup
+		var count int32
+		var fn func(depth int) WorkFuncNested
+
+		// each Work Unit queues two more, filling the queue with the only worker busy
+		fn = func(depth int) WorkFuncNested {
+			return func(queue func(fn WorkFuncNested) *WorkUnit) (interface{}, error) {
+
+				atomic.AddInt32(&count, 1)
+
+				if depth < 5 {
+					wg.Add(2)
+					queue(fn(depth + 1))
+					queue(fn(depth + 1))
+				}
+
+				wg.Done()
+
+				return nil, nil
+			}
+		}
+
+		wg.Add(1)
+		pool.QueueNested(fn(0))
+
+		done := make(chan struct{})
+
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatal("deadlocked queuing from within a WorkFunc")
+		}
+
+		Equal(t, atomic.LoadInt32(&count), int32(63))
+
+		// from a goroutine started by the WorkFunc, eg. one running it with a timeout
+		wu := pool.QueueNested(func(queue func(fn WorkFuncNested) *WorkUnit) (interface{}, error) {
+
+			done := make(chan []*WorkUnit)
+
+			go func() {
+				noop := func(func(fn WorkFuncNested) *WorkUnit) (interface{}, error) { return nil, nil }
+				done <- []*WorkUnit{queue(noop), queue(noop), queue(noop)}
+			}()
+
+			select {
+			case units := <-done:
+				return units, nil
+			case <-time.After(time.Second * 5):
+				return nil, errors.New("blocked queuing from within a WorkFunc")
+			}
+		})
+
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+		WaitAll(wu.Value.([]*WorkUnit)...)
+
+		pool.Close()
+	}
+}
+
+func TestQueueWithProgress(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	step := make(chan struct{})
+	reported := make(chan struct{})
+
+	wu := pool.QueueWithProgress(func(report func(pct float64)) (interface{}, error) {
+
+		for _, pct := range []float64{25, 50, 100} {
+			<-step
+			report(pct)
+			reported <- struct{}{}
+		}
+
+		return "done", nil
+	})
+
+	Equal(t, wu.Progress(), float64(0))
+
+	for _, pct := range []float64{25, 50, 100} {
+		step <- struct{}{}
+		<-reported
+		Equal(t, wu.Progress(), pct)
+	}
+
+	<-wu.Done
+	Equal(t, wu.Value, "done")
+	Equal(t, wu.Progress(), float64(100))
+
+	// not reported by other WorkFuncs
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+	Equal(t, wu.Progress(), float64(0))
+
+	wu = pool.QueueWithProgress(nil)
+	<-wu.Done
+	_, ok := wu.Error.(*ErrNilWorkFunc)
+	Equal(t, ok, true)
+}
+
+func TestWorkUnitWait(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	wu := pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 100)
+		return 1, nil
+	})
+
+	Equal(t, wu.Wait(time.Millisecond*10), false)
+	Equal(t, wu.IsDone(), false)
+
+	// not cancelled and still able to be waited on
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 1)
+
+	Equal(t, wu.Wait(time.Millisecond*10), true)
+	Equal(t, wu.Wait(0), true)
+}
+
+func TestNewAuto(t *testing.T) {
+
+	pool := NewAuto()
+	defer pool.Close()
+
+	Equal(t, pool.workers, uint(runtime.NumCPU()))
+
+	wu := pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	pool = NewAutoMultiple(4)
+	defer pool.Close()
+
+	Equal(t, pool.workers, uint(runtime.NumCPU()*4))
+
+	Equal(t, autoWorkers(8, 0.5), uint(4))
+	Equal(t, autoWorkers(3, 1.5), uint(5))
+	Equal(t, autoWorkers(1, 0.1), uint(1))
+
+	PanicMatches(t, func() { NewAutoMultiple(0) }, "invalid factor '0'")
+	PanicMatches(t, func() { NewAutoMultiple(-1.5) }, "invalid factor '-1.5'")
+}
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"container/heap"
+	"sync/atomic"
+)
+
+// workQueue is a priority queue of Work Units implementing heap.Interface,
+// higher priority units are dequeued first and units of equal priority
+// are dequeued in the order they were queued.
+type workQueue []*WorkUnit
+
+func (q workQueue) Len() int {
+	return len(q)
+}
+
+func (q workQueue) Less(i, j int) bool {
+
+	if q[i].priority == q[j].priority {
+
+		// only ever set when fair, see NewFair
+		if q[i].tag != q[j].tag {
+			return q[i].tag < q[j].tag
+		}
+
+		return q[i].seq < q[j].seq
+	}
+
+	return q[i].priority > q[j].priority
+}
+
+func (q workQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *workQueue) Push(x interface{}) {
+	wu := x.(*WorkUnit)
+	wu.index = len(*q)
+	*q = append(*q, wu)
+}
+
+func (q *workQueue) Pop() interface{} {
+
+	old := *q
+	n := len(old)
+	wu := old[n-1]
+	old[n-1] = nil // don't hold onto the Work Unit any longer than needed
+	*q = old[:n-1]
+
+	return wu
+}
+
+// remove takes a cancelled Work Unit off of the queue, if it's still there, so that
+// it no longer takes up room in a bounded queue or counts towards those pending. It reports
+// whether the pool is done with the Work Unit, those queued outside of the heap are instead
+// settled by whoever takes them off of their queue.
+func (p *Pool) remove(wu *WorkUnit) bool {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if wu.offHeap {
+		return false
+	}
+
+	// the Work Unit's index is only meaningful whilst it's in the queue
+	if i := wu.index; i < len(p.queue) && p.queue[i] == wu {
+		heap.Remove(&p.queue, i)
+		atomic.AddInt64(&p.stats.Load().pending, -1)
+		p.signalNotFull()
+		p.checkDrained()
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket holding a single token, refilled every interval,
+// so that Work Units are started evenly spaced to never exceed the rate.
+type limiter struct {
+	m        sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// reserve takes the next token, as of now, returning how long to wait until it is available.
+func (l *limiter) reserve(now time.Time) time.Duration {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	d := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	return d
+}
+
+// SetRateLimit limits the pool to starting at most qps Work Units per second, regardless
+// of how many workers are idle; useful when calling a third party API with a strict limit.
+// Passing 0 disables the limit. Work Units waiting on the limit are cancelled immediately
+// if the pool is cancelled or closed.
+func (p *Pool) SetRateLimit(qps float64) {
+
+	if qps < 0 {
+		panic(fmt.Sprintf("invalid qps '%v'", qps))
+	}
+
+	var l *limiter
+
+	if qps > 0 {
+		l = &limiter{interval: time.Duration(float64(time.Second) / qps)}
+	}
+
+	p.limiter.Store(l)
+}
+
+// throttle waits for the rate limit, if any, returning false if the pool was
+// cancelled whilst waiting.
+func (p *Pool) throttle(cancel chan struct{}) bool {
+
+	l, _ := p.limiter.Load().(*limiter)
+	if l == nil {
+		return true
+	}
+
+	d := l.reserve(p.now())
+	if d <= 0 {
+		return true
+	}
+
+	t := p.clk().NewTimer(d)
+
+	select {
+	case <-t.C():
+		return true
+	case <-cancel:
+		t.Stop()
+		return false
+	}
+}
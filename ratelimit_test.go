@@ -0,0 +1,90 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSetRateLimit(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(10)
+	defer pool.Close()
+
+	pool.SetRateLimit(20)
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	for _, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+	}
+
+	// first starts immediately then every 50ms
+	Equal(t, time.Since(start) >= time.Millisecond*400, true)
+
+	// disabled
+	pool.SetRateLimit(0)
+
+	start = time.Now()
+	res = res[:0]
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	for _, wu := range res {
+		<-wu.Done
+	}
+
+	Equal(t, time.Since(start) < time.Millisecond*100, true)
+
+	PanicMatches(t, func() { pool.SetRateLimit(-1) }, "invalid qps '-1'")
+}
+
+func TestSetRateLimitCancel(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.SetRateLimit(1)
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	for i := 0; i < 8; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	time.Sleep(time.Millisecond * 50)
+
+	start := time.Now()
+	pool.Cancel()
+
+	var cancelled int
+
+	for _, wu := range res {
+		<-wu.Done
+		if _, ok := wu.Error.(*ErrCancelled); ok {
+			cancelled++
+		}
+	}
+
+	// cancelling doesn't wait on the limiter
+	Equal(t, time.Since(start) < time.Millisecond*100, true)
+	Equal(t, cancelled, 7)
+}
@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// units recycles Work Units released using ReleaseUnit to cut down on allocations.
+var units = sync.Pool{
+	New: func() interface{} {
+		return new(WorkUnit)
+	},
+}
+
+// newUnit returns a recycled Work Unit, if there is one, ready to be queued.
+func newUnit() *WorkUnit {
+	wu := units.Get().(*WorkUnit)
+	wu.Done = make(chan struct{})
+	return wu
+}
+
+// Generation returns the Work Unit's generation, which changes each time it's recycled, to be
+// passed to ReleaseUnit; it must be read when the Work Unit is received, eg. as soon as Queue()
+// returns, not when releasing it.
+func (wu *WorkUnit) Generation() uint64 {
+	return atomic.LoadUint64(&wu.generation)
+}
+
+// ReleaseUnit returns the Work Unit to be recycled by subsequent calls to Queue(), an opt-in fast
+// path for high frequency use that cuts down on allocations. Work Units that are not yet Done, or
+// that the pool is still finishing up with, are left alone and so never recycled, as are those
+// that are held on to elsewhere: a batch's, a Future's, those returned by QueueDedup and those
+// chained on using Then. gen is the Work Unit's Generation() when it was received, the Work Unit is
+// left alone unless it's still the same, so releasing it more than once has no effect even should
+// it since have been recycled and handed out to someone else.
+//
+// WARNING: once released the Work Unit, including it's Value and Error, must not be used again
+// by the caller or anything it was passed to, it may already be running someone else's work; it's
+// Done channel, if held, is not reused and so stays closed.
+// NOTE: the WorkFunc of a Work Unit that was abandoned, see QueueWithTimeout, may still be running.
+func (p *Pool) ReleaseUnit(wu *WorkUnit, gen uint64) {
+
+	if wu.shared || atomic.LoadUint64(&wu.generation) != gen {
+		return
+	}
+
+	// the pool is done with it and it's only released once
+	if !atomic.CompareAndSwapUint32(&wu.settled, 1, 2) {
+		return
+	}
+
+	p.retained.forget(wu)
+
+	*wu = WorkUnit{generation: gen + 1}
+	units.Put(wu)
+}
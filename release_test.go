@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestReleaseUnit(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	block := make(chan struct{})
+
+	wu := pool.Queue(func() (interface{}, error) {
+		<-block
+		return 1, nil
+	})
+
+	gen := wu.Generation()
+
+	// not done so not recycled
+	pool.ReleaseUnit(wu, gen)
+
+	close(block)
+	<-wu.Done
+
+	Equal(t, wu.Value, 1)
+
+	for !settled(wu) {
+		time.Sleep(time.Millisecond)
+	}
+
+	pool.ReleaseUnit(wu, gen)
+
+	Equal(t, wu.Value, nil)
+	Equal(t, wu.Done == nil, true)
+	Equal(t, wu.Generation(), gen+1)
+
+	// released again has no effect
+	pool.ReleaseUnit(wu, gen)
+	Equal(t, wu.Generation(), gen+1)
+
+	for i := 0; i < 100; i++ {
+
+		wu = pool.Queue(func() (interface{}, error) { return i, nil })
+		gen = wu.Generation()
+		<-wu.Done
+
+		Equal(t, wu.Value, i)
+		Equal(t, wu.Error, nil)
+
+		for !settled(wu) {
+			time.Sleep(time.Millisecond)
+		}
+
+		pool.ReleaseUnit(wu, gen)
+	}
+
+	// released again once recycled and handed out to someone else has no effect
+	for i := 0; i < 100; i++ {
+
+		reused := pool.Queue(func() (interface{}, error) { return i, nil })
+		<-reused.Done
+
+		if reused != wu {
+			continue
+		}
+
+		for !settled(reused) {
+			time.Sleep(time.Millisecond)
+		}
+
+		pool.ReleaseUnit(wu, gen)
+
+		Equal(t, reused.Done == nil, false)
+		Equal(t, reused.Value, i)
+		Equal(t, reused.Generation(), gen+1)
+
+		break
+	}
+
+	// cancelled Work Units can be released too
+	block = make(chan struct{})
+
+	running := pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	wu.Cancel()
+
+	Equal(t, settled(wu), true)
+
+	pool.ReleaseUnit(wu, wu.Generation())
+	Equal(t, wu.Done == nil, true)
+
+	close(block)
+	<-running.Done
+
+	// those held on to elsewhere are left alone
+	batch := pool.Batch()
+	bu, _ := batch.QueueChecked(func() (interface{}, error) { return 2, nil })
+	batch.QueueComplete()
+	batch.Wait()
+
+	du := pool.QueueDedup("key", func() (interface{}, error) { return 3, nil })
+	future := pool.Submit(func() (interface{}, error) { return 4, nil })
+	src := pool.Queue(func() (interface{}, error) { return 5, nil })
+	next := src.Then(func(prev interface{}, err error) WorkFunc { return nil })
+
+	<-next.Done
+
+	for _, wu := range []*WorkUnit{bu, du, future.Unit(), src} {
+
+		<-wu.Done
+
+		for !settled(wu) {
+			time.Sleep(time.Millisecond)
+		}
+
+		pool.ReleaseUnit(wu, wu.Generation())
+
+		Equal(t, wu.Done == nil, false)
+		Equal(t, settled(wu), true)
+	}
+
+	Equal(t, bu.Value, 2)
+	Equal(t, batch.Wait().Succeeded, 1)
+	Equal(t, du.Value, 3)
+
+	v, err := future.Get()
+	Equal(t, v, 4)
+	Equal(t, err, nil)
+	Equal(t, src.Value, 5)
+	Equal(t, next.Value, 5)
+}
+
+func settled(wu *WorkUnit) bool {
+	return atomic.LoadUint32(&wu.settled) == 1
+}
@@ -0,0 +1,123 @@
+package pool
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// defaultRequeueLimit is the # of times a Work Unit may be requeued, see ErrRequeue,
+// unless queued with QueueWithRequeueLimit.
+const defaultRequeueLimit = 10
+
+// ErrRequeue may be returned by a WorkFunc that can't proceed right now, eg. a lock it needs
+// is held, to have the pool put the Work Unit back on the queue to be run again later rather
+// than completing it; the Work Unit, and it's Done channel, stay the same. A Work Unit is
+// requeued at most 10 times, see QueueWithRequeueLimit, after which it completes with an
+// ErrRequeueLimit error, or once the pool has been closed with the error the pool was closed with.
+var ErrRequeue = errors.New("pool: requeue Work Unit")
+
+// ErrRequeueLimit is the error set on a Work Unit whose WorkFunc returned ErrRequeue
+// after it had already been requeued as many times as allowed.
+type ErrRequeueLimit struct {
+	s string
+}
+
+// Error prints Work Unit requeue limit error
+func (e *ErrRequeueLimit) Error() string {
+	return e.s
+}
+
+// QueueWithRequeueLimit queues the work to be run, and starts processing immediately, the
+// same as Queue but allowing the Work Unit to be requeued up to max times, see ErrRequeue.
+func (p *Pool) QueueWithRequeueLimit(fn WorkFunc, max int) *WorkUnit {
+
+	if max < 1 {
+		panic(fmt.Sprintf("invalid max '%d'", max))
+	}
+
+	return p.enqueue(&WorkUnit{
+		Done:         make(chan struct{}),
+		fn:           fn,
+		requeueLimit: max,
+	})
+}
+
+// Requeues returns the # of times the Work Unit has been requeued, see ErrRequeue.
+func (wu *WorkUnit) Requeues() int {
+	return int(atomic.LoadInt32(&wu.requeues))
+}
+
+// requeueErr returns an ErrRequeueLimit error once the Work Unit has been requeued as many
+// times as allowed, otherwise nil.
+func (wu *WorkUnit) requeueErr() error {
+
+	limit := wu.requeueLimit
+
+	if limit == 0 {
+		limit = defaultRequeueLimit
+	}
+
+	if int(atomic.LoadInt32(&wu.requeues)) >= limit {
+		return &ErrRequeueLimit{s: fmt.Sprintf(errRequeueLimit, limit)}
+	}
+
+	return nil
+}
+
+// requeue puts the running Work Unit back on the queue, returning nil once it has been or
+// the error the Work Unit should instead be completed with.
+func (p *Pool) requeue(wu *WorkUnit) error {
+
+	if err := wu.requeueErr(); err != nil {
+		return err
+	}
+
+	p.m.Lock()
+
+	if p.closed {
+
+		cancelled := p.cancelled
+		p.m.Unlock()
+
+		if cancelled {
+			return &ErrCancelled{s: errCancelled}
+		}
+
+		return &ErrPoolClosed{s: errClosed}
+	}
+
+	delete(p.active, wu)
+
+	atomic.AddInt32(&wu.requeues, 1)
+	atomic.StoreUint32(&wu.state, stateQueued)
+
+	wu.queued = p.now()
+
+	// back on to the same worker's queue to keep to the key's worker
+	if wu.pinned && len(p.slots) > 0 {
+		p.pinKey(wu)
+	} else {
+		wu.offHeap = false
+		p.sequence(wu)
+		heap.Push(&p.queue, wu)
+		atomic.AddInt64(&p.stats.Load().pending, 1)
+	}
+
+	p.cond.Signal()
+	p.m.Unlock()
+
+	if obs := p.observer(); obs != nil {
+		p.guard("MetricsObserver", obs.OnQueue)
+	}
+
+	p.emit(EventQueued, wu.id, 0, nil)
+
+	// Cancel() called whilst running only flags the Work Unit, now it's queued it can be cancelled
+	if wu.cancelled() {
+		wu.cancelCause(&ErrCancelled{s: errCancelled})
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueRequeue(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var runs int32
+
+	wu := pool.Queue(func() (interface{}, error) {
+
+		if atomic.AddInt32(&runs, 1) <= 2 {
+			return nil, ErrRequeue
+		}
+
+		return "done", nil
+	})
+
+	<-wu.Done
+
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, "done")
+	Equal(t, wu.Requeues(), 2)
+	Equal(t, atomic.LoadInt32(&runs), int32(3))
+	Equal(t, pool.Stats().CompletedCount, int64(1))
+	Equal(t, pool.Stats().RunningCount, int64(0))
+}
+
+func TestQueueWithRequeueLimit(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var runs int32
+
+	wu := pool.QueueWithRequeueLimit(func() (interface{}, error) {
+		atomic.AddInt32(&runs, 1)
+		return nil, ErrRequeue
+	}, 3)
+
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrRequeueLimit)
+	Equal(t, ok, true)
+	Equal(t, wu.Error.Error(), "ERROR: Work Unit not requeued as it's already been requeued 3 times")
+	Equal(t, wu.Requeues(), 3)
+	Equal(t, atomic.LoadInt32(&runs), int32(4))
+
+	// the default limit
+	wu = pool.Queue(func() (interface{}, error) {
+		return nil, ErrRequeue
+	})
+
+	<-wu.Done
+
+	_, ok = wu.Error.(*ErrRequeueLimit)
+	Equal(t, ok, true)
+	Equal(t, wu.Requeues(), defaultRequeueLimit)
+
+	PanicMatches(t, func() { pool.QueueWithRequeueLimit(func() (interface{}, error) { return nil, nil }, 0) }, "invalid max '0'")
+}
@@ -0,0 +1,193 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retained holds the completed Work Units kept for GetResult, see SetResultTTL.
+type retained struct {
+	m     sync.Mutex
+	ttl   int64 // time.Duration, 0 when not retaining
+	max   int
+	units map[uint64]*WorkUnit
+	order []retainedUnit // in completion order, and so expiry order whilst the TTL is unchanged
+	stop  chan struct{}
+}
+
+type retainedUnit struct {
+	id      uint64
+	expires time.Time
+}
+
+// SetResultTTL has the pool retain each Work Unit once completed, for ttl, so that it can be
+// fetched by it's ID using GetResult; supporting a request/response pattern where the results
+// are fetched asynchronously. At most max are retained, the oldest being dropped first to keep
+// memory bounded, 0 for no limit, and a goroutine, which exits when the pool is closed, removes
+// them once expired. Passing a ttl of 0 stops retaining and drops those currently retained.
+//
+// NOTE: a retained Work Unit passed to ReleaseUnit is no longer retained.
+func (p *Pool) SetResultTTL(ttl time.Duration, max int) {
+
+	if ttl < 0 {
+		panic(fmt.Sprintf("invalid ttl '%s'", ttl))
+	}
+
+	if max < 0 {
+		panic(fmt.Sprintf("invalid max '%d'", max))
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	r := &p.retained
+
+	r.m.Lock()
+
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+
+	atomic.StoreInt64(&r.ttl, int64(ttl))
+	r.max = max
+
+	if ttl == 0 {
+		r.units, r.order = nil, nil
+	}
+
+	r.m.Unlock()
+
+	if ttl > 0 && !p.closed {
+		p.startJanitor()
+	}
+}
+
+// GetResult returns the completed Work Unit with the ID, see WorkUnit.ID(), if it's still
+// retained, see SetResultTTL.
+func (p *Pool) GetResult(id uint64) (*WorkUnit, bool) {
+
+	r := &p.retained
+	now := p.now()
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	wu, ok := r.units[id]
+
+	// may not have been removed yet
+	if !ok || now.Sub(wu.completed) >= time.Duration(atomic.LoadInt64(&r.ttl)) {
+		return nil, false
+	}
+
+	return wu, true
+}
+
+// retain keeps the completed Work Unit, when retaining, see SetResultTTL.
+func (p *Pool) retain(wu *WorkUnit) {
+
+	r := &p.retained
+
+	ttl := time.Duration(atomic.LoadInt64(&r.ttl))
+
+	if ttl == 0 {
+		return
+	}
+
+	wu.completed = p.now()
+
+	r.m.Lock()
+
+	if r.units == nil {
+		r.units = make(map[uint64]*WorkUnit)
+	}
+
+	r.units[wu.id] = wu
+	r.order = append(r.order, retainedUnit{id: wu.id, expires: wu.completed.Add(ttl)})
+
+	for r.max > 0 && len(r.units) > r.max {
+		r.drop()
+	}
+
+	r.m.Unlock()
+}
+
+// forget stops retaining the Work Unit, eg. once released.
+func (r *retained) forget(wu *WorkUnit) {
+	r.m.Lock()
+	if r.units[wu.id] == wu {
+		delete(r.units, wu.id)
+	}
+	r.m.Unlock()
+}
+
+// drop removes the oldest retained Work Unit, skipping any already forgotten, must be called with
+// the lock held.
+func (r *retained) drop() {
+	for len(r.order) > 0 {
+
+		id := r.order[0].id
+		r.order = r.order[1:]
+
+		if _, ok := r.units[id]; ok {
+			delete(r.units, id)
+			return
+		}
+	}
+}
+
+// expire removes the retained Work Units that have expired as of now.
+func (r *retained) expire(now time.Time) {
+
+	r.m.Lock()
+
+	for len(r.order) > 0 && !now.Before(r.order[0].expires) {
+
+		delete(r.units, r.order[0].id)
+		r.order = r.order[1:]
+	}
+
+	// don't let the backing array grow forever
+	if len(r.order) == 0 {
+		r.order = nil
+	}
+
+	r.m.Unlock()
+}
+
+// startJanitor starts the goroutine removing expired results, which exits when the pool is
+// closed or the TTL changed; must be called with the lock held.
+func (p *Pool) startJanitor() {
+
+	r := &p.retained
+	stop, cancel := make(chan struct{}), p.cancel
+
+	r.m.Lock()
+	r.stop = stop
+	interval := time.Duration(atomic.LoadInt64(&r.ttl)) / 2
+	r.m.Unlock()
+
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	go func() {
+
+		t := p.clk().NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-cancel:
+				return
+			case <-t.C():
+			}
+
+			r.expire(p.now())
+		}
+	}()
+}
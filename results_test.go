@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+	"gopkg.in/go-playground/pool.v2/clock"
+)
+
+func TestGetResult(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	fake := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	pool.SetClock(fake)
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	// not retained by default
+	wu := pool.Queue(fn)
+	<-wu.Done
+
+	_, ok := pool.GetResult(wu.ID())
+	Equal(t, ok, false)
+
+	pool.SetResultTTL(time.Minute, 0)
+
+	wu = pool.Queue(fn)
+	<-wu.Done
+
+	res, ok := pool.GetResult(wu.ID())
+	Equal(t, ok, true)
+	Equal(t, res == wu, true)
+	Equal(t, res.Value, 1)
+
+	fake.Advance(time.Second * 30)
+
+	_, ok = pool.GetResult(wu.ID())
+	Equal(t, ok, true)
+
+	fake.Advance(time.Second * 30)
+
+	_, ok = pool.GetResult(wu.ID())
+	Equal(t, ok, false)
+
+	// removed by the janitor
+	for {
+		pool.retained.m.Lock()
+		n := len(pool.retained.units)
+		pool.retained.m.Unlock()
+
+		if n == 0 {
+			break
+		}
+
+		fake.Advance(time.Second * 30)
+		time.Sleep(time.Millisecond)
+	}
+
+	// bounded, the oldest dropped first
+	pool.SetResultTTL(time.Hour, 2)
+
+	var res3 []*WorkUnit
+
+	for i := 0; i < 3; i++ {
+		wu = pool.Queue(fn)
+		<-wu.Done
+		res3 = append(res3, wu)
+	}
+
+	_, ok = pool.GetResult(res3[0].ID())
+	Equal(t, ok, false)
+	_, ok = pool.GetResult(res3[1].ID())
+	Equal(t, ok, true)
+	_, ok = pool.GetResult(res3[2].ID())
+	Equal(t, ok, true)
+
+	id := res3[2].ID()
+
+	pool.ReleaseUnit(res3[2], res3[2].Generation())
+	_, ok = pool.GetResult(id)
+	Equal(t, ok, false)
+
+	pool.SetResultTTL(0, 0)
+	_, ok = pool.GetResult(res3[1].ID())
+	Equal(t, ok, false)
+
+	PanicMatches(t, func() { pool.SetResultTTL(-time.Second, 0) }, "invalid ttl '-1s'")
+	PanicMatches(t, func() { pool.SetResultTTL(time.Second, -1) }, "invalid max '-1'")
+}
@@ -0,0 +1,175 @@
+package pool
+
+import "sync/atomic"
+
+// ringSize is the # of Work Units the single producer ring holds before Queue falls back to the
+// pool's queue, must be a power of 2.
+const ringSize = 1024
+
+// ring is a bounded lock free single producer, multiple consumer queue of Work Units.
+type ring struct {
+	head  uint64 // next slot to be consumed, advanced by the consumers
+	tail  uint64 // next slot to be produced, only ever advanced by the producer
+	slots [ringSize]atomic.Pointer[WorkUnit]
+}
+
+// push adds the Work Unit to the ring returning false if it's full,
+// must only ever be called by a single goroutine at a time.
+func (r *ring) push(wu *WorkUnit) bool {
+
+	t := atomic.LoadUint64(&r.tail)
+
+	if t-atomic.LoadUint64(&r.head) >= ringSize {
+		return false
+	}
+
+	r.slots[t&(ringSize-1)].Store(wu)
+	atomic.StoreUint64(&r.tail, t+1)
+
+	return true
+}
+
+// pop takes the oldest Work Unit off of the ring, returning nil if it's empty.
+func (r *ring) pop() *WorkUnit {
+
+	if r == nil {
+		return nil
+	}
+
+	for {
+		h := atomic.LoadUint64(&r.head)
+
+		if h == atomic.LoadUint64(&r.tail) {
+			return nil
+		}
+
+		// the slot can only be reused by the producer once head has moved past it,
+		// in which case the CAS fails and it's read again
+		wu := r.slots[h&(ringSize-1)].Load()
+
+		if atomic.CompareAndSwapUint64(&r.head, h, h+1) {
+			r.slots[h&(ringSize-1)].CompareAndSwap(wu, nil)
+			return wu
+		}
+	}
+}
+
+func (r *ring) len() int {
+
+	if r == nil {
+		return 0
+	}
+
+	return int(atomic.LoadUint64(&r.tail) - atomic.LoadUint64(&r.head))
+}
+
+// SingleProducer switches Queue() over to a lock free ring buffer for when there is only ever a
+// single goroutine queuing work, trimming the latency and contention of queuing at a high rate.
+// Only Work Units queued using Queue() on an unbounded pool, see NewBounded and NewWithBuffer,
+// use the ring and should it fill up they're queued as normal; work the pool queues itself, eg.
+// a Then() chain's or a batch's, is also always queued as normal.
+//
+// NOTE: calling Queue() concurrently from multiple goroutines whilst enabled is not safe, builds
+// using the race detector panic when it's detected; nor is calling Reset() concurrently with Queue().
+// Should be set before any work is queued.
+func (p *Pool) SingleProducer(enabled bool) {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if !enabled {
+		atomic.StoreUint32(&p.single, 0)
+		return
+	}
+
+	// any Work Units still in the ring from when previously enabled are still consumed
+	if p.ring == nil {
+		p.ring = new(ring)
+	}
+
+	atomic.StoreUint32(&p.single, 1)
+}
+
+// pushRing queues the Work Unit using the ring, when the pool is set to SingleProducer,
+// returning false if it must instead be queued as normal.
+func (p *Pool) pushRing(w *WorkUnit) bool {
+
+	if atomic.LoadUint32(&p.single) == 0 || p.inline || w.priority != 0 || p.maxQueued > 0 || p.handoff ||
+		atomic.LoadUint32(&p.open) == 0 || atomic.LoadUint32(&w.state) != stateQueued {
+		return false
+	}
+
+	if raceEnabled {
+		if !atomic.CompareAndSwapUint32(&p.producing, 0, 1) {
+			panic("Queue called concurrently on a SingleProducer pool")
+		}
+		defer atomic.StoreUint32(&p.producing, 0)
+	}
+
+	if w.pool == nil {
+		w.pool = p
+	}
+
+	w.offHeap = true
+	w.queued = p.now()
+	p.stamp(w)
+
+	stats := p.stats.Load()
+
+	// counted before it's visible to the workers so pending never goes negative
+	atomic.AddInt64(&stats.pending, 1)
+
+	if !p.ring.push(w) {
+		atomic.AddInt64(&stats.pending, -1)
+		w.offHeap = false
+		return false
+	}
+
+	atomic.AddInt64(&stats.queued, 1)
+
+	// idle workers count themselves before checking the ring for work one last
+	// time, so either they see this Work Unit or it's seen that they need waking
+	if atomic.LoadInt32(&p.sleeping) > 0 {
+		p.m.Lock()
+		p.cond.Signal()
+		p.m.Unlock()
+	}
+
+	// closed whilst being pushed, the Work Unit may have been missed when the ring was emptied
+	if atomic.LoadUint32(&p.open) == 0 {
+
+		p.m.Lock()
+
+		if p.closed {
+
+			var err error = &ErrPoolClosed{s: errClosed}
+
+			if p.cancelled {
+				err = &ErrCancelled{s: errCancelled}
+			}
+
+			p.emptyRing(err)
+		}
+
+		p.m.Unlock()
+	}
+
+	if obs := p.observer(); obs != nil {
+		p.guard("MetricsObserver", obs.OnQueue)
+	}
+
+	p.emit(EventQueued, w.id, 0, nil)
+
+	return true
+}
+
+// emptyRing cancels all Work Units in the ring with the error, must be called with the lock held.
+func (p *Pool) emptyRing(err error) {
+	for wu := p.ring.pop(); wu != nil; wu = p.ring.pop() {
+
+		atomic.AddInt64(&p.stats.Load().pending, -1)
+
+		wu.cancelWithError(err)
+		wu.settle()
+	}
+}
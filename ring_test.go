@@ -0,0 +1,195 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSingleProducer(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.SingleProducer(true)
+
+	fn := func(i int) WorkFunc {
+		return func() (interface{}, error) {
+			return i, nil
+		}
+	}
+
+	// more than the ring holds so some are queued as normal
+	res := make([]*WorkUnit, ringSize*3)
+
+	for i := range res {
+		res[i] = pool.Queue(fn(i))
+	}
+
+	for i, wu := range res {
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+		Equal(t, wu.Value, i)
+	}
+
+	Equal(t, atomic.LoadInt64(&pool.stats.Load().pending), int64(0))
+
+	// cancelled whilst in the ring
+	pool.Pause()
+
+	cancelled := pool.Queue(fn(1))
+	cancelled.Cancel()
+
+	wu := pool.Queue(fn(2))
+
+	pool.Resume()
+	<-wu.Done
+
+	Equal(t, wu.Value, 2)
+	_, ok := cancelled.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+	Equal(t, atomic.LoadUint32(&cancelled.settled), uint32(1))
+
+	// still in the ring when closed
+	pool.Pause()
+
+	res = res[:0]
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn(i)))
+	}
+
+	pool.Close()
+
+	for _, wu := range res {
+		<-wu.Done
+		_, ok := wu.Error.(*ErrPoolClosed)
+		Equal(t, ok, true)
+	}
+
+	Equal(t, pool.ring.len(), 0)
+	Equal(t, atomic.LoadInt64(&pool.stats.Load().pending), int64(0))
+
+	wu = pool.Queue(fn(1))
+	<-wu.Done
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	pool.Reset()
+
+	res = res[:0]
+
+	for i := 0; i < 100; i++ {
+		res = append(res, pool.Queue(fn(i)))
+	}
+
+	pool.DrainAndWait()
+
+	for i, wu := range res {
+		Equal(t, wu.Error, nil)
+		Equal(t, wu.Value, i)
+	}
+}
+
+func TestSingleProducerConcurrentQueue(t *testing.T) {
+
+	if !raceEnabled {
+		t.Skip("only detected when built using the race detector")
+	}
+
+	pool := New(1)
+	defer pool.Close()
+
+	pool.SingleProducer(true)
+
+	// simulate another goroutine being part way through queuing
+	atomic.StoreUint32(&pool.producing, 1)
+
+	PanicMatches(t, func() {
+		pool.Queue(func() (interface{}, error) { return nil, nil })
+	}, "Queue called concurrently on a SingleProducer pool")
+
+	atomic.StoreUint32(&pool.producing, 0)
+
+	wu := pool.Queue(func() (interface{}, error) { return 1, nil })
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+}
+
+func TestSingleProducerDrain(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	pool.SingleProducer(true)
+
+	release := make(chan struct{})
+
+	running := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	pool.Drain()
+
+	// rejected rather than accepted by the ring
+	wu := pool.Queue(func() (interface{}, error) { return nil, nil })
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+
+	close(release)
+	<-running.Done
+	Equal(t, running.Error, nil)
+}
+
+func TestSingleProducerInternalQueuing(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	pool.SingleProducer(true)
+
+	var units []*WorkUnit
+
+	// the chained Work Units and those of the batch are queued by the pool, not the producer
+	for i := 0; i < 500; i++ {
+
+		i := i
+
+		wu := pool.Queue(func() (interface{}, error) { return i, nil })
+
+		units = append(units, wu.Then(func(prev interface{}, err error) WorkFunc {
+			return func() (interface{}, error) { return prev.(int) * 2, nil }
+		}))
+	}
+
+	batch := pool.BatchWithConcurrency(2)
+
+	for i := 0; i < 100; i++ {
+		batch.Queue(func() (interface{}, error) { return nil, nil })
+		pool.Queue(func() (interface{}, error) { return nil, nil })
+	}
+
+	batch.QueueComplete()
+	Equal(t, batch.Wait().Succeeded, 100)
+
+	for i, wu := range units {
+		<-wu.Done
+		Equal(t, wu.Value, i*2)
+	}
+
+	// as would be the case were the producer part way through queuing
+	atomic.StoreUint32(&pool.producing, 1)
+
+	wu := units[0].Then(func(prev interface{}, err error) WorkFunc {
+		return func() (interface{}, error) { return 1, nil }
+	})
+
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	atomic.StoreUint32(&pool.producing, 0)
+}
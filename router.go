@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	errUnknownRoute = "ERROR: Work Unit not queued as there is no pool named '%s'"
+	errNoClassifier = "ERROR: Work Unit not queued as the Router has no classifier, see RouteFunc"
+)
+
+// ErrUnknownRoute is the error returned by a Router when there is no pool with the name the
+// work was routed to.
+type ErrUnknownRoute struct {
+	Name string
+	s    string
+}
+
+// Error prints Unknown Route error
+func (e *ErrUnknownRoute) Error() string {
+	return e.s
+}
+
+// ErrNoClassifier is the error returned by Router.Queue() when no classifier has been set,
+// see RouteFunc.
+type ErrNoClassifier struct {
+	s string
+}
+
+// Error prints No Classifier error
+func (e *ErrNoClassifier) Error() string {
+	return e.s
+}
+
+// Router dispatches work to one of several named pools, centralizing the queuing of work whilst
+// keeping resource isolation between, for example, CPU and IO bound work. It's only a thin layer
+// over the pools, which are still used, closed and reset directly.
+type Router struct {
+	pools    map[string]*Pool
+	m        sync.RWMutex
+	classify func(fn WorkFunc) string
+}
+
+// NewRouter returns a new Router dispatching to the pools by name, the map is copied so
+// changes to it afterwards have no effect.
+func NewRouter(pools map[string]*Pool) *Router {
+
+	r := &Router{
+		pools: make(map[string]*Pool, len(pools)),
+	}
+
+	for name, p := range pools {
+		r.pools[name] = p
+	}
+
+	return r
+}
+
+// Route queues the work on the named pool, see Pool.Queue(), returning an ErrUnknownRoute error,
+// without queuing the work, if there is no pool with the name.
+func (r *Router) Route(name string, fn WorkFunc) (*WorkUnit, error) {
+
+	p, ok := r.pools[name]
+
+	if !ok {
+		return nil, &ErrUnknownRoute{Name: name, s: fmt.Sprintf(errUnknownRoute, name)}
+	}
+
+	return p.Queue(fn), nil
+}
+
+// RouteFunc sets the classifier used by Queue() to choose the name of the pool to route the
+// work to, see Route().
+func (r *Router) RouteFunc(classify func(fn WorkFunc) string) {
+	r.m.Lock()
+	r.classify = classify
+	r.m.Unlock()
+}
+
+// Queue routes the work to the pool chosen by the classifier, see RouteFunc and Route(). Should
+// no classifier have been set the work isn't queued, the returned Work Unit is already completed
+// with, and the error returned is, an ErrNoClassifier error.
+func (r *Router) Queue(fn WorkFunc) (*WorkUnit, error) {
+
+	r.m.RLock()
+	classify := r.classify
+	r.m.RUnlock()
+
+	if classify == nil {
+
+		err := &ErrNoClassifier{s: errNoClassifier}
+
+		wu := &WorkUnit{
+			Done: make(chan struct{}),
+		}
+
+		wu.complete(nil, err)
+		wu.settle()
+
+		return wu, err
+	}
+
+	return r.Route(classify(fn), fn)
+}
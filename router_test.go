@@ -0,0 +1,83 @@
+package pool
+
+import (
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestRouter(t *testing.T) {
+
+	cpu := New(2)
+	defer cpu.Close()
+
+	io := New(4)
+	defer io.Close()
+
+	pools := map[string]*Pool{"cpu": cpu, "io": io}
+	router := NewRouter(pools)
+
+	// changes to the map have no effect
+	delete(pools, "io")
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	wu, err := router.Route("cpu", fn)
+	Equal(t, err, nil)
+	<-wu.Done
+	Equal(t, wu.pool == cpu, true)
+	Equal(t, wu.Value, 1)
+
+	wu, err = router.Route("io", fn)
+	Equal(t, err, nil)
+	<-wu.Done
+	Equal(t, wu.pool == io, true)
+
+	wu, err = router.Route("gpu", fn)
+	Equal(t, wu == nil, true)
+	Equal(t, err.Error(), "ERROR: Work Unit not queued as there is no pool named 'gpu'")
+
+	e, ok := err.(*ErrUnknownRoute)
+	Equal(t, ok, true)
+	Equal(t, e.Name, "gpu")
+
+	// without a classifier
+	wu, err = router.Queue(fn)
+	<-wu.Done
+	Equal(t, wu.Error, err)
+	Equal(t, err.Error(), "ERROR: Work Unit not queued as the Router has no classifier, see RouteFunc")
+
+	_, ok = err.(*ErrNoClassifier)
+	Equal(t, ok, true)
+
+	var calls int
+
+	router.RouteFunc(func(WorkFunc) string {
+		calls++
+		if calls%2 == 0 {
+			return "io"
+		}
+		return "cpu"
+	})
+
+	wu, err = router.Queue(fn)
+	Equal(t, err, nil)
+	<-wu.Done
+	Equal(t, wu.pool == cpu, true)
+
+	wu, err = router.Queue(fn)
+	Equal(t, err, nil)
+	<-wu.Done
+	Equal(t, wu.pool == io, true)
+
+	// closed pools behave as usual
+	cpu.Close()
+
+	wu, err = router.Route("cpu", fn)
+	Equal(t, err, nil)
+	<-wu.Done
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+}
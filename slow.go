@@ -0,0 +1,87 @@
+package pool
+
+import "time"
+
+// SetSlowThreshold sets a handler that is called, once, for each Work Unit that is still running
+// d after it started, with the time it has been running so far; surfacing stuck work before it
+// times out, if ever. A single goroutine watches the running Work Units, checking every d/2, so
+// the handler is called from it and must be quick. Passing a d of 0 or nil handler removes it.
+func (p *Pool) SetSlowThreshold(d time.Duration, handler func(unit *WorkUnit, elapsed time.Duration)) {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.slowStop != nil {
+		close(p.slowStop)
+		p.slowStop = nil
+	}
+
+	if d <= 0 || handler == nil {
+		p.slowThreshold, p.slowHandler = 0, nil
+		return
+	}
+
+	p.slowThreshold, p.slowHandler = d, handler
+
+	if !p.closed {
+		p.startSlowWatcher()
+	}
+}
+
+// startSlowWatcher starts the goroutine watching for slow Work Units, which exits when the
+// pool is closed or the threshold changed; must be called with the lock held.
+func (p *Pool) startSlowWatcher() {
+
+	d, handler := p.slowThreshold, p.slowHandler
+	stop, cancel := make(chan struct{}), p.cancel
+	p.slowStop = stop
+
+	interval := d / 2
+
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	go func() {
+
+		t := p.clk().NewTicker(interval)
+		defer t.Stop()
+
+		var slow []*WorkUnit
+		var elapsed []time.Duration
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-cancel:
+				return
+			case <-t.C():
+			}
+
+			now := p.now()
+			slow, elapsed = slow[:0], elapsed[:0]
+
+			p.m.Lock()
+
+			for wu := range p.active {
+				if e := now.Sub(wu.started); !wu.slow && !wu.started.IsZero() && e >= d {
+					wu.slow = true
+					slow = append(slow, wu)
+					elapsed = append(elapsed, e)
+				}
+			}
+
+			p.m.Unlock()
+
+			for i, wu := range slow {
+
+				if p.logging() {
+					p.logf("pool: Work Unit %d still running after %s", wu.id, elapsed[i])
+				}
+
+				p.guard("slow handler", func() { handler(wu, elapsed[i]) })
+			}
+		}
+	}()
+}
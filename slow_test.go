@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestSetSlowThreshold(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var m sync.Mutex
+	calls := make(map[*WorkUnit]int)
+
+	pool.SetSlowThreshold(time.Millisecond*20, func(unit *WorkUnit, elapsed time.Duration) {
+		m.Lock()
+		calls[unit]++
+		m.Unlock()
+
+		if elapsed < time.Millisecond*20 {
+			panic("called too soon")
+		}
+	})
+
+	slow := pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 100)
+		return nil, nil
+	})
+
+	fast := pool.Queue(func() (interface{}, error) {
+		return nil, nil
+	})
+
+	WaitAll(slow, fast)
+
+	m.Lock()
+	Equal(t, calls[slow], 1)
+	Equal(t, calls[fast], 0)
+	m.Unlock()
+
+	// restarted on reset
+	pool.Cancel()
+	pool.Reset()
+
+	slow = pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 60)
+		return nil, nil
+	})
+	<-slow.Done
+
+	m.Lock()
+	Equal(t, calls[slow], 1)
+	m.Unlock()
+
+	// removed
+	pool.SetSlowThreshold(0, nil)
+
+	slow = pool.Queue(func() (interface{}, error) {
+		time.Sleep(time.Millisecond * 60)
+		return nil, nil
+	})
+	<-slow.Done
+
+	m.Lock()
+	Equal(t, calls[slow], 0)
+	m.Unlock()
+}
@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PoolSnapshot is a point in time view of the pool, see Snapshot(), that can be serialized as
+// JSON, durations rendering as strings such as "1.5s", eg. for a debug endpoint.
+type PoolSnapshot struct {
+	State     string // one of open, paused, draining or closed
+	Size      uint   // # of workers the pool was created with, or last Resize()d to
+	Workers   int    // # of worker goroutines alive, see WorkerCount()
+	Pending   int    // # of Work Units queued that have yet to start, see Pending()
+	Running   []RunningSnapshot
+	Stats     Stats
+	Latency   LatencyStats // see LatencyStats()
+	QueueWait LatencyStats // see QueueWaitStats()
+}
+
+// RunningSnapshot is a Work Unit that was running when the PoolSnapshot was taken.
+type RunningSnapshot struct {
+	ID      uint64
+	Label   string
+	Elapsed time.Duration // how long it had been running for
+}
+
+// Snapshot returns a point in time view of the pool, composed of it's other accessors, for
+// debugging; the running Work Units are in no particular order.
+//
+// NOTE: the pieces are read one after another so may be very slightly out of sync with one another.
+func (p *Pool) Snapshot() PoolSnapshot {
+
+	p.m.RLock()
+
+	s := PoolSnapshot{
+		State:   "open",
+		Size:    p.workers,
+		Running: make([]RunningSnapshot, 0, len(p.active)),
+	}
+
+	switch {
+	case p.closed:
+		s.State = "closed"
+	case p.draining:
+		s.State = "draining"
+	case p.paused:
+		s.State = "paused"
+	}
+
+	now := p.now()
+
+	for wu := range p.active {
+		s.Running = append(s.Running, RunningSnapshot{ID: wu.id, Label: wu.label, Elapsed: now.Sub(wu.started)})
+	}
+
+	p.m.RUnlock()
+
+	s.Workers = p.WorkerCount()
+	s.Pending = p.Pending()
+	s.Stats = p.Stats()
+	s.Latency = p.LatencyStats()
+	s.QueueWait = p.QueueWaitStats()
+
+	return s
+}
+
+type jsonLatency struct {
+	Count int    `json:"count"`
+	Min   string `json:"min"`
+	Max   string `json:"max"`
+	Mean  string `json:"mean"`
+	P50   string `json:"p50"`
+	P95   string `json:"p95"`
+	P99   string `json:"p99"`
+}
+
+func toJSONLatency(l LatencyStats) jsonLatency {
+	return jsonLatency{
+		Count: l.Count,
+		Min:   l.Min.String(),
+		Max:   l.Max.String(),
+		Mean:  l.Mean.String(),
+		P50:   l.P50.String(),
+		P95:   l.P95.String(),
+		P99:   l.P99.String(),
+	}
+}
+
+// MarshalJSON implements json.Marshaler rendering the durations as strings.
+func (s PoolSnapshot) MarshalJSON() ([]byte, error) {
+
+	type stats struct {
+		Queued    int64 `json:"queued"`
+		Running   int64 `json:"running"`
+		Completed int64 `json:"completed"`
+		Errored   int64 `json:"errored"`
+		Draining  bool  `json:"draining"`
+	}
+
+	type snapshot struct {
+		State     string            `json:"state"`
+		Size      uint              `json:"size"`
+		Workers   int               `json:"workers"`
+		Pending   int               `json:"pending"`
+		Running   []RunningSnapshot `json:"running"`
+		Stats     stats             `json:"stats"`
+		Latency   jsonLatency       `json:"latency"`
+		QueueWait jsonLatency       `json:"queue_wait"`
+	}
+
+	return json.Marshal(snapshot{
+		State:   s.State,
+		Size:    s.Size,
+		Workers: s.Workers,
+		Pending: s.Pending,
+		Running: s.Running,
+		Stats: stats{
+			Queued:    s.Stats.QueuedCount,
+			Running:   s.Stats.RunningCount,
+			Completed: s.Stats.CompletedCount,
+			Errored:   s.Stats.ErroredCount,
+			Draining:  s.Stats.Draining,
+		},
+		Latency:   toJSONLatency(s.Latency),
+		QueueWait: toJSONLatency(s.QueueWait),
+	})
+}
+
+// MarshalJSON implements json.Marshaler rendering the elapsed duration as a string.
+func (r RunningSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID      uint64 `json:"id"`
+		Label   string `json:"label,omitempty"`
+		Elapsed string `json:"elapsed"`
+	}{
+		ID:      r.ID,
+		Label:   r.Label,
+		Elapsed: r.Elapsed.String(),
+	})
+}
@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+	"gopkg.in/go-playground/pool.v2/clock"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	fake := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	pool.SetClock(fake)
+
+	release := make(chan struct{})
+
+	wu := pool.QueueLabeled(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}, "job")
+
+	for pool.Stats().RunningCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	fake.Advance(time.Second * 90)
+
+	s := pool.Snapshot()
+
+	Equal(t, s.State, "open")
+	Equal(t, s.Size, uint(2))
+	Equal(t, s.Workers, 2)
+	Equal(t, s.Pending, 0)
+	Equal(t, len(s.Running), 1)
+	Equal(t, s.Running[0].ID, wu.ID())
+	Equal(t, s.Running[0].Label, "job")
+	Equal(t, s.Running[0].Elapsed, time.Second*90)
+	Equal(t, s.Stats.QueuedCount, int64(1))
+
+	b, err := json.Marshal(s)
+	Equal(t, err, nil)
+
+	var decoded map[string]interface{}
+	Equal(t, json.Unmarshal(b, &decoded), nil)
+
+	Equal(t, decoded["state"], "open")
+	Equal(t, decoded["workers"], float64(2))
+	Equal(t, decoded["running"], []interface{}{map[string]interface{}{"id": float64(wu.ID()), "label": "job", "elapsed": "1m30s"}})
+	Equal(t, decoded["stats"].(map[string]interface{})["queued"], float64(1))
+	Equal(t, decoded["stats"].(map[string]interface{})["draining"], false)
+	Equal(t, decoded["latency"].(map[string]interface{})["p99"], "0s")
+
+	b, err = json.Marshal(PoolSnapshot{Stats: Stats{Draining: true}})
+	Equal(t, err, nil)
+	Equal(t, json.Unmarshal(b, &decoded), nil)
+	Equal(t, decoded["stats"].(map[string]interface{})["draining"], true)
+
+	close(release)
+	<-wu.Done
+
+	pool.Pause()
+	Equal(t, pool.Snapshot().State, "paused")
+	pool.Resume()
+
+	pool.Close()
+	Equal(t, pool.Snapshot().State, "closed")
+}
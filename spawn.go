@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkFuncSpawn is the function type needed by the pool for work that forks into sub units of
+// work, queued using the passed function, that it's Work Unit then joins on, see QueueSpawn
+type WorkFuncSpawn func(spawn func(fn WorkFunc) *WorkUnit) (interface{}, error)
+
+// QueueSpawn queues the work to be run, and starts processing immediately, passing a function the
+// WorkFunc can call to queue child Work Units on the pool, eg. for tree structured work; the Work
+// Unit isn't Done until both the WorkFunc has returned and all of the children it spawned have
+// completed, it's Error being the WorkFunc's error joined, using errors.Join, with the errors of
+// those children that failed, see SetErrorClassifier.
+//
+// So that a saturated pool can't deadlock with every worker waiting on children that no worker
+// is free to run, the worker joining on the children runs those that have yet to start itself.
+//
+// NOTE: spawn must only be called from within the WorkFunc, not once it has returned.
+func (p *Pool) QueueSpawn(fn WorkFuncSpawn) *WorkUnit {
+
+	wu := &WorkUnit{
+		Done: make(chan struct{}),
+	}
+
+	// left without a WorkFunc so that push rejects it as it does any other nil WorkFunc
+	if fn == nil {
+		return p.enqueue(wu)
+	}
+
+	wu.fn = func() (interface{}, error) {
+
+		var m sync.Mutex
+		var children []*WorkUnit
+
+		spawn := func(fn WorkFunc) *WorkUnit {
+
+			// never blocks, the parent's worker may be the only one able to make room
+			child := p.enqueue(&WorkUnit{
+				Done:   make(chan struct{}),
+				fn:     fn,
+				nested: true,
+			})
+
+			m.Lock()
+			children = append(children, child)
+			m.Unlock()
+
+			return child
+		}
+
+		value, err := fn(spawn)
+
+		m.Lock()
+		defer m.Unlock()
+
+		errs := []error{err}
+
+		for _, child := range children {
+
+			p.join(child)
+
+			if p.failure(child.Error) {
+				errs = append(errs, child.Error)
+			}
+		}
+
+		return value, errors.Join(errs...)
+	}
+
+	return p.enqueue(wu)
+}
+
+// join waits for the Work Unit to complete, running it on the calling goroutine should it still
+// be queued so that a worker waiting on it doesn't wait on itself, see QueueSpawn.
+func (p *Pool) join(wu *WorkUnit) {
+
+	if p.claim(wu) {
+		p.runHere(wu)
+	}
+
+	<-wu.Done
+}
+
+// claim takes the Work Unit off of whichever queue it's on, the shared queue or a worker's own,
+// returning whether it did so, in which case the caller must run it.
+func (p *Pool) claim(wu *WorkUnit) bool {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if wu.offHeap {
+
+		for _, w := range p.slots {
+			for i, u := range w.local {
+
+				if u != wu {
+					continue
+				}
+
+				if !atomic.CompareAndSwapUint32(&wu.state, stateQueued, stateRunning) {
+					return false
+				}
+
+				copy(w.local[i:], w.local[i+1:])
+				w.local[len(w.local)-1] = nil
+				w.local = w.local[:len(w.local)-1]
+				p.local--
+				atomic.AddInt64(&p.stats.Load().pending, -1)
+				p.signalNotFull()
+
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// the Work Unit's index is only meaningful whilst it's in the queue
+	i := wu.index
+
+	if i >= len(p.queue) || p.queue[i] != wu || !atomic.CompareAndSwapUint32(&wu.state, stateQueued, stateRunning) {
+		return false
+	}
+
+	heap.Remove(&p.queue, i)
+	atomic.AddInt64(&p.stats.Load().pending, -1)
+	p.signalNotFull()
+
+	return true
+}
@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueSpawn(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var completed int32
+
+	wu := pool.QueueSpawn(func(spawn func(fn WorkFunc) *WorkUnit) (interface{}, error) {
+
+		for i := 0; i < 3; i++ {
+			spawn(func() (interface{}, error) {
+				atomic.AddInt32(&completed, 1)
+				return nil, nil
+			})
+		}
+
+		return "parent", nil
+	})
+
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, "parent")
+	Equal(t, atomic.LoadInt32(&completed), int32(3))
+
+	// the children's errors are joined with the parent's
+	failed := errors.New("failed")
+	parentErr := errors.New("parent failed")
+
+	wu = pool.QueueSpawn(func(spawn func(fn WorkFunc) *WorkUnit) (interface{}, error) {
+
+		spawn(func() (interface{}, error) { return nil, failed })
+		spawn(func() (interface{}, error) { return nil, nil })
+		spawn(func() (interface{}, error) { return nil, failed })
+
+		return nil, parentErr
+	})
+
+	<-wu.Done
+	Equal(t, errors.Is(wu.Error, parentErr), true)
+	Equal(t, errors.Is(wu.Error, failed), true)
+	Equal(t, len(wu.Error.(interface{ Unwrap() []error }).Unwrap()), 3)
+}
+
+func TestQueueSpawnSaturated(t *testing.T) {
+
+	for _, pool := range []*Pool{New(1), NewWorkStealing(1), NewSync()} {
+
+		var completed int32
+
+		// every worker is a parent waiting on it's children
+		wu := pool.QueueSpawn(func(spawn func(fn WorkFunc) *WorkUnit) (interface{}, error) {
+
+			for i := 0; i < 3; i++ {
+				spawn(func() (interface{}, error) {
+					atomic.AddInt32(&completed, 1)
+					return nil, nil
+				})
+			}
+
+			return nil, nil
+		})
+
+		<-wu.Done
+		Equal(t, wu.Error, nil)
+		Equal(t, atomic.LoadInt32(&completed), int32(3))
+		Equal(t, pool.Pending(), 0)
+
+		pool.Close()
+	}
+}
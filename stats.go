@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats contains a snapshot of the pools counters since it was created or last Reset().
+type Stats struct {
+	QueuedCount    int64 // total # of Work Units queued
+	RunningCount   int64 // # of Work Units currently executing
+	CompletedCount int64 // total # of Work Units that have finished executing, with or without error
+	ErroredCount   int64 // total # of Work Units that have finished executing with an error that's a failure, see SetErrorClassifier
+	Draining       bool  // whether the pool is draining, no longer accepting work, see Drain()
+}
+
+// stats holds the live counters, they are only ever accessed atomically.
+type stats struct {
+	queued    int64
+	pending   int64
+	running   int64
+	completed int64
+	errored   int64
+	latency   reservoir
+	queueWait reservoir
+}
+
+func (s *stats) started() {
+	atomic.AddInt64(&s.running, 1)
+}
+
+// requeued undoes started for a Work Unit put back on the queue, see ErrRequeue.
+func (s *stats) requeued() {
+	atomic.AddInt64(&s.running, -1)
+}
+
+func (s *stats) finished(d time.Duration, failed bool) {
+
+	atomic.AddInt64(&s.running, -1)
+	atomic.AddInt64(&s.completed, 1)
+	s.latency.record(d)
+
+	if failed {
+		atomic.AddInt64(&s.errored, 1)
+	}
+}
+
+// Stats returns a snapshot of the pools counters, reading them is lock free, never contending
+// with the workers for the pool's lock, but they are read individually so may be very slightly
+// out of sync with one another. The counters are reset when the pool is Reset().
+func (p *Pool) Stats() Stats {
+
+	s := p.stats.Load()
+
+	return Stats{
+		QueuedCount:    atomic.LoadInt64(&s.queued),
+		RunningCount:   atomic.LoadInt64(&s.running),
+		CompletedCount: atomic.LoadInt64(&s.completed),
+		ErroredCount:   atomic.LoadInt64(&s.errored),
+		Draining:       atomic.LoadUint32(&p.drainStat) == 1,
+	}
+}
+
+// Pending returns the # of queued Work Units that have not yet started executing.
+//
+// NOTE: the value is inherently racy, it is only a momentary snapshot as Work Units
+// may be queued or started at any time.
+func (p *Pool) Pending() int {
+	return int(atomic.LoadInt64(&p.stats.Load().pending))
+}
+
+// RunningUnits returns a snapshot of the Work Units currently being executed by the workers,
+// useful along with their ID() for seeing what the pool is doing, eg. from a debug endpoint.
+// The snapshot may be momentarily stale as Work Units start and finish at any time.
+func (p *Pool) RunningUnits() []*WorkUnit {
+
+	p.m.RLock()
+
+	units := make([]*WorkUnit, 0, len(p.active))
+
+	for wu := range p.active {
+		units = append(units, wu)
+	}
+
+	p.m.RUnlock()
+
+	return units
+}
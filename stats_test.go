@@ -0,0 +1,189 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestStats(t *testing.T) {
+
+	var res []*WorkUnit
+
+	pool := New(4)
+	defer pool.Close()
+
+	newFunc := func(i int) WorkFunc {
+		return func() (interface{}, error) {
+			time.Sleep(time.Millisecond * 20)
+			if i%10 == 0 {
+				return nil, errors.New("bad unit")
+			}
+			return i, nil
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		res = append(res, pool.Queue(newFunc(i)))
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		for _, wu := range res {
+			<-wu.Done
+		}
+		close(done)
+	}()
+
+	var max int64
+
+FOR:
+	for {
+		select {
+		case <-done:
+			break FOR
+		default:
+			if r := pool.Stats().RunningCount; r > max {
+				max = r
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	Equal(t, max <= 4, true)
+	Equal(t, max > 0, true)
+
+	s := pool.Stats()
+	Equal(t, s.QueuedCount, int64(100))
+	Equal(t, s.RunningCount, int64(0))
+	Equal(t, s.CompletedCount, int64(100))
+	Equal(t, s.ErroredCount, int64(10))
+
+	// read without the pool's lock
+	read := make(chan Stats)
+
+	pool.m.Lock()
+
+	go func() {
+		pool.Pending()
+		pool.LatencyStats()
+		pool.QueueWaitStats()
+		read <- pool.Stats()
+	}()
+
+	select {
+	case s = <-read:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Stats blocked on the pool's lock")
+	}
+
+	pool.m.Unlock()
+
+	Equal(t, s.CompletedCount, int64(100))
+
+	pool.Cancel()
+	pool.Reset()
+
+	Equal(t, pool.Stats(), Stats{})
+
+	// draining until closed
+	release := make(chan struct{})
+
+	wu := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	pool.Drain()
+	Equal(t, pool.Stats().Draining, true)
+
+	close(release)
+	<-wu.Done
+	<-pool.Idle()
+
+	for pool.Snapshot().State != "closed" {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, pool.Stats().Draining, false)
+}
+
+func TestPending(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 10; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	for pool.Stats().RunningCount != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	Equal(t, pool.Pending(), 8)
+
+	close(release)
+
+	for _, wu := range res {
+		<-wu.Done
+	}
+
+	Equal(t, pool.Pending(), 0)
+
+	pool.Pause()
+	pool.Queue(fn)
+	pool.Queue(fn)
+	Equal(t, pool.Pending(), 2)
+
+	pool.Cancel()
+	Equal(t, pool.Pending(), 0)
+}
+
+func TestRunningUnits(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	Equal(t, len(pool.RunningUnits()), 0)
+
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 5; i++ {
+		res = append(res, pool.Queue(fn))
+	}
+
+	for len(pool.RunningUnits()) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, wu := range pool.RunningUnits() {
+		Equal(t, wu == res[0] || wu == res[1], true)
+	}
+
+	close(release)
+	WaitAll(res...)
+
+	for len(pool.RunningUnits()) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
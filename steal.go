@@ -0,0 +1,54 @@
+package pool
+
+import "sync/atomic"
+
+// NewWorkStealing returns a new pool instance where each worker has it's own queue, a deque,
+// that queued work is spread across in turn; each worker runs the Work Units from the head of
+// it's own queue and, once it's empty, steals from the tail of the busiest other worker's queue,
+// balancing uneven workloads without all of the work being dealt out up front staying where it
+// landed, as it does with QueueAffinity. Priorities don't apply, and work queued with
+// QueueAffinity may be stolen so the key is only a hint of which worker runs it.
+func NewWorkStealing(workers uint) *Pool {
+
+	p := newPool(workers, 0)
+
+	p.m.Lock()
+	p.stealing = true
+	p.m.Unlock()
+
+	return p
+}
+
+// pushLocal adds the Work Unit to the tail of each worker's queue in turn, see NewWorkStealing;
+// must be called with the lock held and only when there are workers.
+func (p *Pool) pushLocal(wu *WorkUnit) {
+	p.turn++
+	p.pin(p.slots[p.turn%uint64(len(p.slots))], wu)
+}
+
+// steal takes the Work Unit from the tail of the queue of the worker, other than w, with the
+// most queued, returning nil if none of them have any; must be called with the lock held.
+func (p *Pool) steal(w *worker) *WorkUnit {
+
+	var victim *worker
+
+	for _, s := range p.slots {
+		if s != w && len(s.local) > 0 && (victim == nil || len(s.local) > len(victim.local)) {
+			victim = s
+		}
+	}
+
+	if victim == nil {
+		return nil
+	}
+
+	n := len(victim.local) - 1
+	wu := victim.local[n]
+	victim.local[n] = nil
+	victim.local = victim.local[:n]
+
+	p.local--
+	atomic.AddInt64(&p.stats.Load().pending, -1)
+
+	return wu
+}
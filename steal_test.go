@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewWorkStealing(t *testing.T) {
+
+	pool := NewWorkStealing(4)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	blocker := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	var res []*WorkUnit
+
+	// some are queued behind the blocker, on the same worker, so can only run if stolen
+	for i := 0; i < 20; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond)
+			return 1, nil
+		}))
+	}
+
+	for _, wu := range res {
+		if !wu.Wait(time.Second) {
+			t.Fatal("Work Unit queued behind a blocked worker should have been stolen")
+		}
+		Equal(t, wu.Value, 1)
+	}
+
+	close(release)
+	<-blocker.Done
+
+	// cancelled whilst on a worker's queue
+	release = make(chan struct{})
+	res = res[:0]
+
+	for i := 0; i < 4; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			<-release
+			return nil, nil
+		}))
+	}
+
+	for pool.Stats().RunningCount < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancelled := pool.Queue(func() (interface{}, error) { return 1, nil })
+	cancelled.Cancel()
+	<-cancelled.Done
+
+	_, ok := cancelled.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	close(release)
+
+	<-pool.Idle()
+	Equal(t, pool.Pending(), 0)
+}
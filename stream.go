@@ -0,0 +1,130 @@
+package pool
+
+import (
+	"bytes"
+	"io"
+)
+
+// QueueStream queues the work, which streams it's output, to be run and starts processing
+// immediately; the output is piped to the Work Unit's Reader() so it can be consumed whilst the
+// WorkFunc is still running rather than held in memory, eg. a large report. Once the WorkFunc
+// returns the reader returns io.EOF, or the error it returned, after the last of the output.
+//
+// Writes block until read, so the Reader() must be read from, or closed to abandon the output,
+// after which writes return io.ErrClosedPipe. Should the Work Unit be cancelled, or otherwise
+// complete without the WorkFunc returning, eg. timing out, the reader returns the Work Unit's error
+// and writes from then on return it too.
+//
+// NOTE: on a NewSync pool the WorkFunc has run by the time QueueStream returns, so it's output is
+// instead held in memory until read.
+func (p *Pool) QueueStream(fn WorkFuncStream) *WorkUnit {
+
+	wu := &WorkUnit{
+		Done: make(chan struct{}),
+	}
+
+	// left without a WorkFunc so that push rejects it as it does any other nil WorkFunc
+	if fn == nil {
+		r := &bufferedReader{buf: new(bytes.Buffer)}
+		wu.stream = r
+		p.enqueue(wu)
+		r.err = wu.Error
+		return wu
+	}
+
+	if p.inline {
+
+		buf := new(bytes.Buffer)
+		r := &bufferedReader{buf: buf}
+		wu.stream = r
+
+		wu.fn = func() (interface{}, error) {
+			return nil, fn(&streamWriter{wu: wu, w: buf})
+		}
+
+		p.enqueue(wu)
+		r.err = wu.Error
+
+		return wu
+	}
+
+	pr, pw := io.Pipe()
+	wu.stream = pr
+
+	wu.fn = func() (interface{}, error) {
+		err := fn(&streamWriter{wu: wu, w: pw, pw: pw})
+		pw.CloseWithError(err)
+		return nil, err
+	}
+
+	p.enqueue(wu)
+
+	// for when it completes without the WorkFunc returning, only the first close has any effect
+	go func() {
+
+		<-wu.Done
+
+		if wu.Error != nil {
+			pw.CloseWithError(wu.Error)
+			return
+		}
+
+		pw.Close()
+	}()
+
+	return wu
+}
+
+// Reader returns the output of a Work Unit queued with QueueStream, which is nil for Work Units
+// queued by any other means.
+func (wu *WorkUnit) Reader() io.ReadCloser {
+	return wu.stream
+}
+
+// streamWriter is the writer passed to a WorkFuncStream, stopping the output once it's Work
+// Unit has been cancelled whilst running.
+type streamWriter struct {
+	wu *WorkUnit
+	w  io.Writer
+	pw *io.PipeWriter // nil when buffered, see NewSync
+}
+
+func (s *streamWriter) Write(b []byte) (int, error) {
+
+	if s.wu.cancelled() {
+
+		err := &ErrCancelled{s: errCancelled}
+
+		if s.pw != nil {
+			s.pw.CloseWithError(err)
+		}
+
+		return 0, err
+	}
+
+	return s.w.Write(b)
+}
+
+// bufferedReader is the output of a WorkFuncStream run on a NewSync pool, returning the
+// WorkFunc's error, if any, once it's all been read.
+type bufferedReader struct {
+	buf *bytes.Buffer
+	err error
+}
+
+func (r *bufferedReader) Read(b []byte) (int, error) {
+
+	n, err := r.buf.Read(b)
+
+	if err == io.EOF && r.err != nil {
+		return n, r.err
+	}
+
+	return n, err
+}
+
+// Close discards the rest of the output.
+func (r *bufferedReader) Close() error {
+	r.buf.Reset()
+	return nil
+}
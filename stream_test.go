@@ -0,0 +1,143 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueueStream(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	wrote := make(chan struct{})
+
+	wu := pool.QueueStream(func(w io.Writer) error {
+
+		fmt.Fprint(w, "line 1\n")
+		close(wrote)
+
+		for i := 2; i <= 3; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+
+		return nil
+	})
+
+	// consumed whilst the Work Unit is still running
+	buf := make([]byte, 7)
+	_, err := io.ReadFull(wu.Reader(), buf)
+	Equal(t, err, nil)
+	Equal(t, string(buf), "line 1\n")
+
+	<-wrote
+	Equal(t, wu.IsDone(), false)
+
+	rest, err := io.ReadAll(wu.Reader())
+	Equal(t, err, nil)
+	Equal(t, string(rest), "line 2\nline 3\n")
+
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+
+	// the WorkFunc's error is returned after the output
+	failed := errors.New("failed")
+
+	wu = pool.QueueStream(func(w io.Writer) error {
+		fmt.Fprint(w, "partial")
+		return failed
+	})
+
+	rest, err = io.ReadAll(wu.Reader())
+	Equal(t, err, failed)
+	Equal(t, string(rest), "partial")
+
+	// closing the reader fails the writes
+	wu = pool.QueueStream(func(w io.Writer) error {
+		_, err := fmt.Fprint(w, "ignored")
+		return err
+	})
+
+	wu.Reader().Close()
+	<-wu.Done
+	Equal(t, wu.Error, io.ErrClosedPipe)
+
+	// a nil WorkFuncStream is rejected, the reader returning the error
+	wu = pool.QueueStream(nil)
+	_, err = io.ReadAll(wu.Reader())
+	_, ok := err.(*ErrNilWorkFunc)
+	Equal(t, ok, true)
+
+	Equal(t, pool.Queue(func() (interface{}, error) { return nil, nil }).Reader(), nil)
+}
+
+func TestQueueStreamCancel(t *testing.T) {
+
+	pool := New(1)
+	defer pool.Close()
+
+	release := make(chan struct{})
+	pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	// cancelled before it starts
+	wu := pool.QueueStream(func(w io.Writer) error {
+		return nil
+	})
+
+	wu.Cancel()
+
+	_, err := io.ReadAll(wu.Reader())
+	_, ok := err.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	close(release)
+
+	// cancelled whilst running
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	wu = pool.QueueStream(func(w io.Writer) error {
+
+		close(started)
+		<-cancelled
+
+		_, err := w.Write([]byte("too late"))
+		return err
+	})
+
+	<-started
+	wu.Cancel()
+	close(cancelled)
+
+	_, err = io.ReadAll(wu.Reader())
+	_, ok = err.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	<-wu.Done
+	_, ok = wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+}
+
+func TestQueueStreamSync(t *testing.T) {
+
+	pool := NewSync()
+	defer pool.Close()
+
+	wu := pool.QueueStream(func(w io.Writer) error {
+		fmt.Fprint(w, "report")
+		return nil
+	})
+
+	Equal(t, wu.IsDone(), true)
+
+	b, err := io.ReadAll(wu.Reader())
+	Equal(t, err, nil)
+	Equal(t, string(b), "report")
+}
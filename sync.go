@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// NewSync returns a new pool instance, for testing, without any workers, each WorkFunc instead
+// runs synchronously on the goroutine that queues it so that Queue returns an already completed
+// Work Unit, giving deterministic and easily debugged behaviour whilst keeping the same API as
+// any other pool. A batch's Work Units run in the order queued once QueueComplete() is called,
+// on it's caller's goroutine, after which their results are delivered in that same order.
+//
+// NOTE: the worker count is ignored, Resize() has no effect, as do Pause() and SingleProducer(),
+// and a WorkFuncState is passed state created for the Work Unit alone, see NewWithWorkerState.
+func NewSync() *Pool {
+
+	p := &Pool{
+		inline: true,
+		active: make(map[*WorkUnit]struct{}),
+		m:      new(sync.RWMutex),
+	}
+
+	p.cond = sync.NewCond(p.m)
+	p.notFull = sync.NewCond(p.m)
+	p.drained = sync.NewCond(p.m)
+	p.initialize()
+
+	return p
+}
+
+// runInline runs the accepted Work Unit on the calling goroutine, as a worker would, for
+// pools created by NewSync.
+func (p *Pool) runInline(wu *WorkUnit) {
+
+	if !atomic.CompareAndSwapUint32(&wu.state, stateQueued, stateRunning) {
+		wu.settle()
+		return
+	}
+
+	p.runHere(wu)
+}
+
+// runHere runs the Work Unit, which must already have been moved to the running state, on the
+// calling goroutine as a worker would; see runInline and join.
+func (p *Pool) runHere(wu *WorkUnit) {
+
+	p.m.Lock()
+	p.active[wu] = struct{}{}
+	wu.started = p.now()
+	p.m.Unlock()
+
+	p.runUnit(&worker{cancel: p.cancel, stats: p.stats.Load()}, wu)
+}
+
+// runInline runs the batch's Work Units in the order queued, for pools created by NewSync,
+// and then delivers their results in that same order. Only called once the batch is closed
+// so no more Work Units can be added.
+func (b *Batch) runInline() {
+
+	for _, wu := range b.units {
+
+		b.pool.enqueue(wu)
+
+		// so CancelOnError stops those after it from running
+		if b.pool.failure(wu.Error) {
+			b.failed(wu.Error)
+		}
+	}
+
+	go func(b *Batch) {
+		for _, wu := range b.units {
+			b.reportProgress(true)
+			b.deliver(wu)
+			b.wg.Done()
+		}
+	}(b)
+}
@@ -0,0 +1,153 @@
+package pool
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewSync(t *testing.T) {
+
+	pool := NewSync()
+	defer pool.Close()
+
+	caller := goroutineID()
+
+	var ran int64
+
+	wu := pool.Queue(func() (interface{}, error) {
+		ran = goroutineID()
+		return 1, nil
+	})
+
+	// completed before Queue returns
+	select {
+	case <-wu.Done:
+	default:
+		t.Fatal("Work Unit should be Done once queued on a sync pool")
+	}
+
+	Equal(t, wu.Value, 1)
+	Equal(t, wu.Error, nil)
+	Equal(t, ran, caller)
+	Equal(t, pool.Stats().CompletedCount, int64(1))
+
+	wu = pool.Queue(func() (interface{}, error) {
+		panic("boom")
+	})
+
+	_, ok := wu.Error.(*PanicError)
+	Equal(t, ok, true)
+
+	var runs int
+
+	wu = pool.Queue(func() (interface{}, error) {
+
+		if runs++; runs <= 2 {
+			return nil, ErrRequeue
+		}
+
+		return "done", nil
+	})
+
+	Equal(t, wu.Value, "done")
+	Equal(t, wu.Requeues(), 2)
+
+	pool.Resize(4)
+	Equal(t, pool.WorkerCount(), 0)
+
+	pool.Close()
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil })
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+}
+
+func TestNewSyncBatch(t *testing.T) {
+
+	pool := NewSync()
+	defer pool.Close()
+
+	var order []int
+
+	batch := pool.Batch()
+
+	for i := 0; i < 10; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+			order = append(order, i)
+			return i, nil
+		})
+	}
+
+	// nothing runs until QueueComplete()
+	Equal(t, len(order), 0)
+
+	batch.QueueComplete()
+	Equal(t, len(order), 10)
+
+	var i int
+
+	for wu := range batch.Results() {
+		Equal(t, wu.Value, i)
+		Equal(t, order[i], i)
+		i++
+	}
+
+	Equal(t, i, 10)
+
+	fail := errors.New("fail")
+	runs := 0
+
+	batch = pool.Batch()
+	batch.CancelOnError()
+
+	for i := 0; i < 5; i++ {
+		i := i
+		batch.Queue(func() (interface{}, error) {
+			runs++
+
+			if i == 1 {
+				return nil, fail
+			}
+
+			return i, nil
+		})
+	}
+
+	batch.QueueComplete()
+	Equal(t, runs, 2)
+
+	var cancelled int
+
+	for wu := range batch.Results() {
+		if _, ok := wu.Error.(*ErrCancelled); ok {
+			cancelled++
+		}
+	}
+
+	Equal(t, cancelled, 3)
+	Equal(t, batch.Err(), fail)
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// "goroutine 123 [running]:" header of it's stack trace.
+func goroutineID() int64 {
+
+	var buf [64]byte
+
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+
+	return id
+}
@@ -0,0 +1,137 @@
+package pool
+
+// Then chains work on to the Work Unit, once it completes fn is called with it's Value and Error
+// to build the WorkFunc of the next Work Unit which is then queued on the same pool; the returned
+// Work Unit is that next Work Unit, completing when it does, so that chains such as
+// a.Then(b).Then(c) can be built without wiring up channels by hand. fn decides whether
+// to proceed, eg. after an error, returning nil short-circuits the chain and the next Work
+// Unit completes with this Work Unit's Value and Error instead.
+//
+// fn is called on whichever worker runs the next Work Unit, so a panic within it is recovered
+// like any other WorkFunc's, and cancelling the next Work Unit before this one completes means
+// it's never queued and fn never called.
+//
+// NOTE: should this Work Unit never have been accepted by a pool, eg. queued after Close(), there
+// is no pool to queue the next Work Unit on, it completes with this Work Unit's Value and Error
+// without fn being called.
+func (wu *WorkUnit) Then(fn func(prev interface{}, err error) WorkFunc) *WorkUnit {
+
+	wu.shared = true
+
+	next := &WorkUnit{
+		Done: make(chan struct{}),
+	}
+
+	next.fn = func() (interface{}, error) {
+
+		if f := fn(wu.Value, wu.Error); f != nil {
+			return f()
+		}
+
+		return wu.Value, wu.Error
+	}
+
+	chain := func() {
+
+		<-wu.Done
+
+		if wu.pool == nil {
+			next.complete(wu.Value, wu.Error)
+			next.settle()
+			return
+		}
+
+		if next.IsDone() {
+			return
+		}
+
+		wu.pool.enqueue(next)
+	}
+
+	// queued straight away so a NewSync pool returns an already completed Work Unit, the pool
+	// is only safe to read once done as the Work Unit may itself be yet to be queued
+	if wu.IsDone() && wu.pool != nil && wu.pool.inline {
+		chain()
+		return next
+	}
+
+	go chain()
+
+	return next
+}
+
+// ThenBatch chains a second batch on to the batch, once all of it's Work Units have completed fn
+// is called with them, in the order they were queued, to build the second batch, eg. fetch all
+// then process all; the returned batch's Results() are those of the second batch. The second batch
+// needn't have QueueComplete() called, it's called once fn returns, and when fn returns nil the
+// returned batch completes without any results.
+//
+// Cancelling the returned batch cancels both stages, and should this batch be cancelled the second
+// stage never starts, fn isn't called and the returned batch is cancelled too completing without
+// any results.
+//
+// NOTE: this batch's Results() are read by ThenBatch, and the returned batch's results come solely
+// from the second stage so no work should be queued on it directly.
+// WARNING: QueueComplete() must be called on this batch, otherwise the second stage never starts.
+func (b *Batch) ThenBatch(fn func(results []*WorkUnit) *Batch) *Batch {
+
+	next := b.pool.Batch()
+	next.upstream = []*Batch{b}
+
+	go func() {
+
+		b.Wait()
+
+		b.m.Lock()
+		units, cancelled := b.units, b.cancelled
+		b.m.Unlock()
+
+		if cancelled {
+			next.Cancel()
+			return
+		}
+
+		var stage *Batch
+
+		b.pool.guard("ThenBatch callback", func() { stage = fn(units) })
+
+		if stage == nil {
+			next.QueueComplete()
+			return
+		}
+
+		stage.QueueComplete()
+
+		next.m.Lock()
+		next.upstream = append(next.upstream, stage)
+		cancelled = next.cancelled
+		next.m.Unlock()
+
+		// cancelled whilst fn was building the second stage
+		if cancelled {
+			stage.Cancel()
+		}
+
+		for wu := range stage.Results() {
+
+			next.m.Lock()
+
+			if next.closed {
+				next.m.Unlock()
+				continue
+			}
+
+			next.units = append(next.units, wu)
+			next.wg.Add(1)
+			next.m.Unlock()
+
+			next.reportProgress(true)
+			next.deliver(wu)
+			next.wg.Done()
+		}
+
+		next.QueueComplete()
+	}()
+
+	return next
+}
@@ -0,0 +1,246 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestThen(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var order []string
+
+	wu := pool.Queue(func() (interface{}, error) {
+		order = append(order, "a")
+		return 1, nil
+	}).Then(func(prev interface{}, err error) WorkFunc {
+		return func() (interface{}, error) {
+			order = append(order, "b")
+			return prev.(int) + 1, err
+		}
+	}).Then(func(prev interface{}, err error) WorkFunc {
+		return func() (interface{}, error) {
+			order = append(order, "c")
+			return prev.(int) * 10, err
+		}
+	})
+
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, 20)
+	Equal(t, order, []string{"a", "b", "c"})
+}
+
+func TestThenShortCircuit(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	failed := errors.New("failed")
+	var ran bool
+
+	wu := pool.Queue(func() (interface{}, error) {
+		return nil, failed
+	}).Then(func(prev interface{}, err error) WorkFunc {
+
+		if err != nil {
+			return nil
+		}
+
+		return func() (interface{}, error) {
+			ran = true
+			return nil, nil
+		}
+	}).Then(func(prev interface{}, err error) WorkFunc {
+
+		// proceeds regardless, recovering from the error
+		return func() (interface{}, error) {
+			return "recovered from " + err.Error(), nil
+		}
+	})
+
+	<-wu.Done
+	Equal(t, ran, false)
+	Equal(t, wu.Error, nil)
+	Equal(t, wu.Value, "recovered from failed")
+
+	// cancelled before the previous Work Unit completes so never queued
+	release := make(chan struct{})
+
+	first := pool.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	var called bool
+
+	wu = first.Then(func(prev interface{}, err error) WorkFunc {
+		called = true
+		return nil
+	})
+
+	wu.Cancel()
+	close(release)
+	<-first.Done
+	<-wu.Done
+
+	_, ok := wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	time.Sleep(time.Millisecond * 20)
+	Equal(t, called, false)
+
+	// panics are recovered like any other WorkFunc's
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil }).Then(func(prev interface{}, err error) WorkFunc {
+		panic("boom")
+	})
+
+	<-wu.Done
+	_, ok = wu.Error.(*PanicError)
+	Equal(t, ok, true)
+
+	// never accepted by the pool
+	pool.Close()
+
+	wu = pool.Queue(func() (interface{}, error) { return nil, nil }).Then(func(prev interface{}, err error) WorkFunc {
+		called = true
+		return nil
+	})
+
+	<-wu.Done
+	_, ok = wu.Error.(*ErrPoolClosed)
+	Equal(t, ok, true)
+	Equal(t, called, false)
+}
+
+func TestThenSync(t *testing.T) {
+
+	pool := NewSync()
+	defer pool.Close()
+
+	wu := pool.Queue(func() (interface{}, error) {
+		return 1, nil
+	}).Then(func(prev interface{}, err error) WorkFunc {
+		return func() (interface{}, error) { return prev.(int) + 1, nil }
+	}).Then(func(prev interface{}, err error) WorkFunc {
+		return func() (interface{}, error) { return prev.(int) + 1, nil }
+	})
+
+	Equal(t, wu.IsDone(), true)
+	Equal(t, wu.Value, 3)
+}
+
+func TestThenBatch(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	fetch := pool.Batch()
+
+	for i := 1; i <= 5; i++ {
+		i := i
+		fetch.Queue(func() (interface{}, error) {
+			return i, nil
+		})
+	}
+
+	fetch.QueueComplete()
+
+	transform := fetch.ThenBatch(func(results []*WorkUnit) *Batch {
+
+		b := pool.Batch()
+
+		for _, wu := range results {
+			v := wu.Value.(int)
+			b.Queue(func() (interface{}, error) {
+				return v * 10, nil
+			})
+		}
+
+		return b
+	})
+
+	var sum int
+
+	for wu := range transform.Results() {
+		Equal(t, wu.Error, nil)
+		sum += wu.Value.(int)
+	}
+
+	Equal(t, sum, 150)
+
+	r := transform.Wait()
+	Equal(t, r.Total, 5)
+	Equal(t, r.Succeeded, 5)
+}
+
+func TestThenBatchCancel(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	release := make(chan struct{})
+
+	fetch := pool.Batch()
+	fetch.Queue(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	fetch.QueueComplete()
+
+	var called bool
+
+	next := fetch.ThenBatch(func(results []*WorkUnit) *Batch {
+		called = true
+		return pool.Batch()
+	})
+
+	fetch.Cancel()
+	close(release)
+
+	var count int
+
+	for range next.Results() {
+		count++
+	}
+
+	Equal(t, count, 0)
+	Equal(t, called, false)
+
+	// cancelling the returned batch cancels the first stage
+	release = make(chan struct{})
+
+	fetch = pool.Batch()
+
+	for i := 0; i < 5; i++ {
+		fetch.Queue(func() (interface{}, error) {
+			<-release
+			return nil, nil
+		})
+	}
+
+	fetch.QueueComplete()
+
+	next = fetch.ThenBatch(func(results []*WorkUnit) *Batch {
+		called = true
+		return pool.Batch()
+	})
+
+	next.Cancel()
+	close(release)
+
+	r := fetch.Wait()
+	Equal(t, r.Failed > 0, true)
+
+	for range next.Results() {
+		count++
+	}
+
+	Equal(t, count, 0)
+	Equal(t, called, false)
+}
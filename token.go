@@ -0,0 +1,100 @@
+package pool
+
+import "sync"
+
+// CancelToken is a reusable cancellation handle for cancelling a logical group of Work Units
+// that may span multiple batches and pools, see QueueToken. It must be created using
+// NewCancelToken and is safe for concurrent use.
+type CancelToken struct {
+	m         sync.Mutex
+	units     map[*WorkUnit]struct{}
+	cancelled bool
+}
+
+// NewCancelToken returns a new CancelToken.
+func NewCancelToken() *CancelToken {
+	return &CancelToken{
+		units: make(map[*WorkUnit]struct{}),
+	}
+}
+
+// Cancel cancels all of the Work Units queued with the token that have yet to complete, see
+// WorkUnit.Cancel(), along with any queued with it afterwards. Calling it more than once does nothing.
+func (t *CancelToken) Cancel() {
+
+	t.m.Lock()
+
+	if t.cancelled {
+		t.m.Unlock()
+		return
+	}
+
+	t.cancelled = true
+	units := t.units
+	t.units = nil
+	t.m.Unlock()
+
+	// unlocked as cancelling completes the Work Units which removes them from the token
+	for wu := range units {
+		wu.Cancel()
+	}
+}
+
+// IsCancelled reports whether Cancel() has been called.
+func (t *CancelToken) IsCancelled() bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.cancelled
+}
+
+// add associates the Work Unit with the token, cancelling it immediately if the token
+// already has been; must be called before the Work Unit is queued.
+func (t *CancelToken) add(wu *WorkUnit) {
+
+	t.m.Lock()
+
+	if t.cancelled {
+		t.m.Unlock()
+		wu.Cancel()
+		return
+	}
+
+	wu.token = t
+	t.units[wu] = struct{}{}
+	t.m.Unlock()
+}
+
+// remove forgets the completed Work Unit so a long lived token doesn't hold onto it.
+func (t *CancelToken) remove(wu *WorkUnit) {
+	t.m.Lock()
+	delete(t.units, wu)
+	t.m.Unlock()
+}
+
+// QueueToken queues the work to be run, and starts processing immediately, associated with the
+// token so that it's cancelled should the token be, see CancelToken; a Work Unit queued with an
+// already cancelled token is Done immediately with an ErrCancelled error.
+func (p *Pool) QueueToken(tok *CancelToken, fn WorkFunc) *WorkUnit {
+
+	wu := &WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	}
+
+	tok.add(wu)
+
+	return p.enqueue(wu)
+}
+
+// QueueToken is the same as Queue() but with the Work Unit associated with the token,
+// see Pool.QueueToken().
+func (b *Batch) QueueToken(tok *CancelToken, fn WorkFunc) {
+
+	wu := &WorkUnit{
+		Done: make(chan struct{}),
+		fn:   fn,
+	}
+
+	tok.add(wu)
+	b.queueUnit(wu)
+}
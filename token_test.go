@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"testing"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestCancelToken(t *testing.T) {
+
+	p1 := New(1)
+	defer p1.Close()
+
+	p2 := New(1)
+	defer p2.Close()
+
+	fn := func() (interface{}, error) {
+		return 1, nil
+	}
+
+	tok := NewCancelToken()
+
+	// forgotten once completed
+	wu := p1.QueueToken(tok, fn)
+	<-wu.Done
+	Equal(t, wu.Value, 1)
+
+	tok.m.Lock()
+	Equal(t, len(tok.units), 0)
+	tok.m.Unlock()
+
+	p1.Pause()
+	p2.Pause()
+
+	var res []*WorkUnit
+
+	for i := 0; i < 5; i++ {
+		res = append(res, p1.QueueToken(tok, fn), p2.QueueToken(tok, fn))
+	}
+
+	b1, b2 := p1.Batch(), p2.Batch()
+
+	for i := 0; i < 5; i++ {
+		b1.QueueToken(tok, fn)
+		b2.QueueToken(tok, fn)
+	}
+
+	b1.QueueComplete()
+	b2.QueueComplete()
+
+	// other work is unaffected
+	other := p1.Queue(fn)
+
+	Equal(t, tok.IsCancelled(), false)
+	tok.Cancel()
+	tok.Cancel()
+	Equal(t, tok.IsCancelled(), true)
+
+	for _, wu := range res {
+		<-wu.Done
+		_, ok := wu.Error.(*ErrCancelled)
+		Equal(t, ok, true)
+	}
+
+	p1.Resume()
+	p2.Resume()
+
+	for _, b := range []*Batch{b1, b2} {
+		res := b.Wait()
+		Equal(t, res.Total, 5)
+		Equal(t, res.Failed, 5)
+	}
+
+	<-other.Done
+	Equal(t, other.Error, nil)
+
+	// already cancelled
+	wu = p1.QueueToken(tok, fn)
+	<-wu.Done
+	_, ok := wu.Error.(*ErrCancelled)
+	Equal(t, ok, true)
+
+	// running Work Units are told they've been cancelled
+	tok = NewCancelToken()
+	started := make(chan struct{})
+
+	wu = p1.enqueue(func() *WorkUnit {
+		wu := &WorkUnit{
+			Done: make(chan struct{}),
+			fnCancel: func(cancelled func() bool) (interface{}, error) {
+				close(started)
+				for !cancelled() {
+				}
+				return nil, nil
+			},
+		}
+		tok.add(wu)
+		return wu
+	}())
+
+	<-started
+	tok.Cancel()
+	<-wu.Done
+	Equal(t, wu.Error, nil)
+}
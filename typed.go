@@ -0,0 +1,136 @@
+package pool
+
+import "sync"
+
+// TypedWorkFunc is the function type needed by the TypedPool
+type TypedWorkFunc[T any] func() (T, error)
+
+// TypedWorkUnit contains a single unit of works values, with the Value typed
+// so that no casting is needed after the Work Unit is Done.
+type TypedWorkUnit[T any] struct {
+	*WorkUnit
+	Value T
+}
+
+// TypedPool is a Pool whose Work Units all return a value of type T.
+// It runs on the same workers as a regular Pool, only wrapping the WorkFunc's
+// in order to capture the typed value.
+type TypedPool[T any] struct {
+	pool *Pool
+}
+
+// NewTyped returns a new typed pool instance.
+func NewTyped[T any](workers uint) *TypedPool[T] {
+	return &TypedPool[T]{pool: New(workers)}
+}
+
+// Queue queues the work to be run, and starts processing immediately
+func (p *TypedPool[T]) Queue(fn TypedWorkFunc[T]) *TypedWorkUnit[T] {
+
+	tu := new(TypedWorkUnit[T])
+	tu.WorkUnit = p.pool.Queue(tu.wrap(fn))
+
+	return tu
+}
+
+// wrap converts the typed WorkFunc into a regular WorkFunc, storing the typed value
+// prior to returning so it is set before the Done channel is closed.
+func (tu *TypedWorkUnit[T]) wrap(fn TypedWorkFunc[T]) WorkFunc {
+
+	// left to the pool to report
+	if fn == nil {
+		return nil
+	}
+
+	return func() (interface{}, error) {
+		v, err := fn()
+		tu.Value = v
+		return v, err
+	}
+}
+
+// Reset reinitializes a pool that has been closed/cancelled back to a working state, see Pool.Reset().
+func (p *TypedPool[T]) Reset() error {
+	return p.pool.Reset()
+}
+
+// Cancel cleans up the pool workers and channels and cancels and pending
+// work still yet to be processed.
+func (p *TypedPool[T]) Cancel() {
+	p.pool.Cancel()
+}
+
+// Close cleans up the pool workers and channels and cancels any pending
+// work still yet to be processed.
+func (p *TypedPool[T]) Close() {
+	p.pool.Close()
+}
+
+// TypedBatch contains all information for a batch run of TypedWorkUnits
+type TypedBatch[T any] struct {
+	batch *Batch
+	m     *sync.Mutex
+	units map[*WorkUnit]*TypedWorkUnit[T]
+}
+
+// Batch creates a new TypedBatch object, see Pool.Batch() for details.
+func (p *TypedPool[T]) Batch() *TypedBatch[T] {
+	return &TypedBatch[T]{
+		batch: p.pool.Batch(),
+		m:     new(sync.Mutex),
+		units: make(map[*WorkUnit]*TypedWorkUnit[T]),
+	}
+}
+
+// Queue queues the work to be run in the pool and starts processing immediately
+// and also retains a reference for Cancellation and outputting to results.
+// WARNING be sure to call QueueComplete() once all work has been Queued.
+func (b *TypedBatch[T]) Queue(fn TypedWorkFunc[T]) {
+
+	tu := new(TypedWorkUnit[T])
+
+	// locked until the unit is recorded so Results() can't see it before then.
+	b.m.Lock()
+
+	if wu := b.batch.queue(tu.wrap(fn)); wu != nil {
+		tu.WorkUnit = wu
+		b.units[wu] = tu
+	}
+
+	b.m.Unlock()
+}
+
+// QueueComplete lets the batch know that there will be no more Work Units Queued
+// so that it may close the results channels once all work is completed.
+func (b *TypedBatch[T]) QueueComplete() {
+	b.batch.QueueComplete()
+}
+
+// Cancel cancels the Work Units belonging to this Batch
+func (b *TypedBatch[T]) Cancel() {
+	b.batch.Cancel()
+}
+
+// Results returns a TypedWorkUnit result channel that will output all
+// completed units of work.
+func (b *TypedBatch[T]) Results() <-chan *TypedWorkUnit[T] {
+
+	results := make(chan *TypedWorkUnit[T])
+
+	go func(b *TypedBatch[T]) {
+
+		for wu := range b.batch.Results() {
+
+			b.m.Lock()
+			tu := b.units[wu]
+			delete(b.units, wu)
+			b.m.Unlock()
+
+			results <- tu
+		}
+
+		close(results)
+	}(b)
+
+	return results
+}
@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestTypedPool(t *testing.T) {
+
+	pool := NewTyped[string](2)
+	defer pool.Close()
+
+	user := pool.Queue(func() (string, error) {
+		time.Sleep(time.Millisecond * 100)
+		return "Joeybloggs", nil
+	})
+
+	bad := pool.Queue(func() (string, error) {
+		return "", errors.New("bad user")
+	})
+
+	<-user.Done
+	Equal(t, user.Error, nil)
+	Equal(t, user.Value, "Joeybloggs")
+
+	<-bad.Done
+	NotEqual(t, bad.Error, nil)
+	Equal(t, bad.Error.Error(), "bad user")
+	Equal(t, bad.Value, "")
+}
+
+func TestTypedBatch(t *testing.T) {
+
+	pool := NewTyped[int](4)
+	defer pool.Close()
+
+	batch := pool.Batch()
+
+	for i := 1; i <= 4; i++ {
+		i := i
+		batch.Queue(func() (int, error) {
+			time.Sleep(time.Millisecond * 100)
+			return i, nil
+		})
+	}
+
+	batch.QueueComplete()
+
+	var sum int
+
+	for wu := range batch.Results() {
+		Equal(t, wu.Error, nil)
+		sum += wu.Value
+	}
+
+	Equal(t, sum, 10)
+}
+
+func TestTypedQueueNilWorkFunc(t *testing.T) {
+
+	pool := NewTyped[int](1)
+	defer pool.Close()
+
+	wu := pool.Queue(nil)
+	<-wu.Done
+
+	Equal(t, wu.Value, 0)
+	_, ok := wu.Error.(*ErrNilWorkFunc)
+	Equal(t, ok, true)
+}
@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// WaitStrategy controls how idle workers wait for new work, see NewWithWaitStrategy.
+type WaitStrategy uint8
+
+// Wait strategies
+const (
+	// Blocking idle workers block until woken by new work, using no CPU whilst idle.
+	Blocking WaitStrategy = iota
+
+	// SpinThenBlock idle workers busy spin briefly checking for new work before blocking,
+	// trimming the latency of bursty workloads at the cost of CPU.
+	SpinThenBlock
+
+	// Yielding idle workers yield the processor to other goroutines whilst briefly checking
+	// for new work before blocking, a middle ground between Blocking and SpinThenBlock.
+	Yielding
+)
+
+// spinIterations is the # of times an idle worker checks for new work before blocking.
+const spinIterations = 256
+
+// NewWithWaitStrategy returns a new pool instance whose idle workers wait for new work using
+// the strategy; New() uses Blocking.
+func NewWithWaitStrategy(workers uint, strategy WaitStrategy) *Pool {
+
+	p := newPool(workers, 0)
+
+	p.m.Lock()
+	p.strategy = strategy
+	p.m.Unlock()
+
+	return p
+}
+
+// spin releases the lock whilst the worker checks for new work according to the wait strategy,
+// returning once some arrives or it gives up; must be called with the lock held, which is held
+// again on return.
+func (p *Pool) spin(w *worker) {
+
+	p.m.Unlock()
+	defer p.m.Lock()
+
+	for i := 0; i < spinIterations; i++ {
+
+		if atomic.LoadInt64(&w.stats.pending) > 0 {
+			return
+		}
+
+		if p.strategy == Yielding {
+			runtime.Gosched()
+		}
+	}
+}
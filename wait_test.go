@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewWithWaitStrategy(t *testing.T) {
+
+	for _, strategy := range []WaitStrategy{Blocking, SpinThenBlock, Yielding} {
+
+		pool := NewWithWaitStrategy(4, strategy)
+
+		var res []*WorkUnit
+
+		for i := 0; i < 100; i++ {
+			i := i
+			res = append(res, pool.Queue(func() (interface{}, error) {
+				return i, nil
+			}))
+
+			// let the workers go idle between bursts
+			if i%10 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		for i, wu := range res {
+			<-wu.Done
+			Equal(t, wu.Value, i)
+		}
+
+		// paused workers aren't left spinning
+		pool.Pause()
+		wu := pool.Queue(func() (interface{}, error) { return 1, nil })
+		time.Sleep(time.Millisecond * 10)
+		Equal(t, wu.IsDone(), false)
+
+		pool.Resume()
+		<-wu.Done
+
+		Equal(t, wu.Value, 1)
+
+		pool.Close()
+	}
+}
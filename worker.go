@@ -0,0 +1,420 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// worker contains everything belonging to a single consumer goroutine.
+type worker struct {
+	cancel chan struct{} // the pools cancel channel when started, so workers from before a Reset() exit
+	quit   chan struct{} // closed when the worker is to exit due to the pool shrinking
+	stats  *stats
+	id     int
+
+	state    interface{}
+	hasState bool
+	local    []*WorkUnit // affinity queue, guarded by the pool's lock, see QueueAffinity
+	notified bool        // whether the OnWorkerStart hook has been called
+}
+
+// workerState returns the worker's state, creating it on first use.
+// only ever called from the worker's goroutine so needs no locking.
+func (w *worker) workerState(p *Pool) interface{} {
+
+	if p.stateFactory == nil {
+		return nil
+	}
+
+	if !w.hasState {
+		w.state = p.stateFactory()
+		w.hasState = true
+	}
+
+	return w.state
+}
+
+// discardState drops the worker's state so it is created anew on it's next use.
+func (w *worker) discardState() {
+	w.state = nil
+	w.hasState = false
+}
+
+// newWorker starts the consumer goroutine for the worker
+func (p *Pool) newWorker(w *worker) {
+	p.goStart(func() {
+
+		atomic.AddInt32(&p.live, 1)
+		defer atomic.AddInt32(&p.live, -1)
+
+		p.workerStarted(w)
+
+		for {
+
+			wu := p.next(w)
+
+			if wu == nil {
+				p.workerStopped(w)
+				return
+			}
+
+			// in case the hook was set after the worker started
+			p.workerStarted(w)
+
+			// need to fire up new worker to replace this one as this one is exiting,
+			// the WorkFunc may have left the state in a bad way so it's discarded
+			if p.runUnit(w, wu) {
+				w.discardState()
+				p.newWorker(w)
+				return
+			}
+		}
+	})
+}
+
+// runUnit runs the Work Unit, already taken off of the queue by the worker, to completion on the
+// calling goroutine, unless it's requeued, see ErrRequeue, reporting whether it panicked.
+func (p *Pool) runUnit(w *worker, wu *WorkUnit) (panicked bool) {
+
+	var end func(error)
+	var obs MetricsObserver
+	var start time.Time
+
+	defer func() {
+		if err := recover(); err != nil {
+
+			panicked = true
+
+			perr := p.recoveryError(wu, err)
+			p.recordPanics(wu, perr)
+			rerr := p.wrapError(wu, perr)
+			d := p.now().Sub(start)
+			w.stats.finished(d, p.failure(rerr))
+
+			if end != nil {
+				p.guard("tracer", func() { end(rerr) })
+			}
+
+			if obs != nil {
+				p.guard("MetricsObserver", func() { obs.OnComplete(d, rerr) })
+			}
+
+			wu.complete(nil, rerr)
+			p.emitDone(wu, rerr)
+			p.finished(wu)
+		}
+	}()
+
+	ctx := wu.ctx
+
+	if ctx == nil {
+		ctx = context.Background()
+	} else if err := ctx.Err(); err != nil {
+		wu.complete(nil, err)
+		p.emitDone(wu, err)
+		p.finished(wu)
+		return false
+	}
+
+	if !p.throttle(w.cancel) {
+		err := &ErrCancelled{s: errCancelled}
+		wu.complete(nil, err)
+		p.emitDone(wu, err)
+		p.finished(wu)
+		return false
+	}
+
+	if tracer, ok := p.tracer.Load().(func(context.Context, *WorkUnit) (context.Context, func(error))); ok && tracer != nil {
+		p.guard("tracer", func() { ctx, end = tracer(ctx, wu) })
+	}
+
+	w.stats.started()
+
+	if obs = p.observer(); obs != nil {
+		p.guard("MetricsObserver", obs.OnStart)
+	}
+
+	p.emit(EventStarted, wu.id, w.id, nil)
+
+	start = p.now()
+
+	w.stats.queueWait.record(start.Sub(wu.queued))
+
+	v, err := p.execute(wu, ctx, w)
+
+	if errors.Is(err, ErrRequeue) && !p.inline {
+
+		if err = p.requeue(wu); err == nil {
+			w.stats.requeued()
+
+			if end != nil {
+				p.guard("tracer", func() { end(ErrRequeue) })
+			}
+
+			if obs != nil {
+				p.guard("MetricsObserver", func() { obs.OnComplete(p.now().Sub(start), ErrRequeue) })
+			}
+
+			return false
+		}
+	}
+
+	// there's no queue to return to, see NewSync, so it's run again straight away
+	for errors.Is(err, ErrRequeue) {
+
+		if wu.cancelled() {
+			err = &ErrCancelled{s: errCancelled}
+			break
+		}
+
+		if err = wu.requeueErr(); err != nil {
+			break
+		}
+
+		atomic.AddInt32(&wu.requeues, 1)
+		v, err = p.execute(wu, ctx, w)
+	}
+
+	p.recordPanics(wu, err)
+	err = p.wrapError(wu, err)
+	d := p.now().Sub(start)
+	w.stats.finished(d, p.failure(err))
+
+	if end != nil {
+		p.guard("tracer", func() { end(err) })
+	}
+
+	if obs != nil {
+		p.guard("MetricsObserver", func() { obs.OnComplete(d, err) })
+	}
+
+	wu.complete(v, err)
+	p.emitDone(wu, err)
+	p.finished(wu)
+
+	return false
+}
+
+// OnWorkerStart sets a hook that is called from each worker's goroutine, with the worker's ID,
+// as it starts, or before it runs it's next Work Unit for workers started before the hook was set;
+// for per worker setup such as opening a connection, see OnWorkerStop. A worker replaced after a
+// WorkFunc panics keeps it's ID and isn't started again. Passing nil removes the hook.
+func (p *Pool) OnWorkerStart(fn func(workerID int)) {
+	p.workerStart.Store(fn)
+}
+
+// OnWorkerStop sets a hook that is called from each worker's goroutine, with the worker's ID,
+// as it exits, whether due to the pool being closed, cancelled or shrunk by Resize(), so that
+// resources set up by OnWorkerStart can always be released. Passing nil removes the hook.
+func (p *Pool) OnWorkerStop(fn func(workerID int)) {
+	p.workerStop.Store(fn)
+}
+
+func (p *Pool) workerStarted(w *worker) {
+
+	if w.notified {
+		return
+	}
+
+	if fn, ok := p.workerStart.Load().(func(int)); ok && fn != nil {
+		w.notified = true
+		p.guard("OnWorkerStart hook", func() { fn(w.id) })
+	}
+}
+
+func (p *Pool) workerStopped(w *worker) {
+
+	p.emit(EventWorkerStopped, 0, w.id, nil)
+
+	if fn, ok := p.workerStop.Load().(func(int)); ok && fn != nil {
+		p.guard("OnWorkerStop hook", func() { fn(w.id) })
+	}
+}
+
+// WorkerCount returns the # of worker goroutines that are currently alive, which can differ from
+// the size the pool was created with, or Resize()d to, whilst workers are still starting up or
+// exiting, eg. surplus workers only exit once they've finished their current Work Unit.
+func (p *Pool) WorkerCount() int {
+	return int(atomic.LoadInt32(&p.live))
+}
+
+// next blocks until there is a Work Unit for the worker to run, returning nil
+// if instead the worker should exit due to the pool being closed/cancelled or shrunk.
+func (p *Pool) next(w *worker) *WorkUnit {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	var spun bool
+
+	for {
+		select {
+		case <-w.cancel:
+			return nil
+		case <-w.quit:
+			return nil
+		default:
+		}
+
+		if wu := p.dequeue(w); wu != nil {
+
+			// support for individual WorkUnit cancellation
+			// and batch job cancellation
+			if !atomic.CompareAndSwapUint32(&wu.state, stateQueued, stateRunning) {
+
+				if wu.offHeap {
+					wu.settle()
+				}
+
+				p.checkDrained()
+				continue
+			}
+
+			p.active[wu] = struct{}{}
+			wu.started = p.now()
+
+			return wu
+		}
+
+		// check again for work before blocking, only once so a paused pool isn't spun on
+		if p.strategy != Blocking && !spun {
+			spun = true
+			p.spin(w)
+			continue
+		}
+
+		// counted before checking one last time so a single producer knows to wake it, see pushRing
+		r := p.ring
+
+		if r != nil {
+
+			atomic.AddInt32(&p.sleeping, 1)
+
+			if !p.paused && r.len() > 0 {
+				atomic.AddInt32(&p.sleeping, -1)
+				continue
+			}
+		}
+
+		p.idle++
+
+		// a producer may be waiting to hand off to an idle worker
+		if p.handoff {
+			p.signalNotFull()
+		}
+
+		p.cond.Wait()
+		p.idle--
+
+		if r != nil {
+			atomic.AddInt32(&p.sleeping, -1)
+		}
+	}
+}
+
+// dequeue takes the worker's next Work Unit off of it's affinity queue, the queue or the single
+// producer ring, returning nil if there are none or the pool is paused; must be called with the lock held.
+func (p *Pool) dequeue(w *worker) *WorkUnit {
+
+	if p.paused {
+		return nil
+	}
+
+	if len(w.local) > 0 {
+		wu := w.local[0]
+		w.local[0] = nil
+		w.local = w.local[1:]
+		p.local--
+		atomic.AddInt64(&p.stats.Load().pending, -1)
+		p.signalNotFull()
+		return wu
+	}
+
+	if len(p.queue) > 0 {
+		wu := heap.Pop(&p.queue).(*WorkUnit)
+		p.vtime = wu.tag
+		atomic.AddInt64(&p.stats.Load().pending, -1)
+		p.signalNotFull()
+		return wu
+	}
+
+	if p.stealing {
+		if wu := p.steal(w); wu != nil {
+			p.signalNotFull()
+			return wu
+		}
+	}
+
+	if wu := p.ring.pop(); wu != nil {
+		atomic.AddInt64(&p.stats.Load().pending, -1)
+		return wu
+	}
+
+	return nil
+}
+
+// finished removes the Work Unit from the set of those running.
+func (p *Pool) finished(wu *WorkUnit) {
+
+	p.m.Lock()
+	delete(p.active, wu)
+	p.checkDrained()
+	p.m.Unlock()
+
+	// the batch's results are bounded so the worker waits on the consumer
+	if wu.delivered != nil {
+		<-wu.delivered
+	}
+
+	wu.settle()
+}
+
+// checkDrained wakes anyone waiting for the pool to have nothing queued or running,
+// must be called with the lock held.
+func (p *Pool) checkDrained() {
+	if p.isIdle() {
+
+		p.drained.Broadcast()
+
+		if p.idleCh != nil {
+			close(p.idleCh)
+			p.idleCh = nil
+		}
+	}
+}
+
+// isIdle reports whether the pool has nothing queued or running, must be called with the lock held.
+func (p *Pool) isIdle() bool {
+	return len(p.queue) == 0 && p.ring.len() == 0 && p.local == 0 && len(p.active) == 0
+}
+
+// Idle returns a channel that is closed once the pool has nothing queued or running, which is
+// immediately if that's already the case when called; useful for synchronizing tests or shutdown.
+// Each call reflects the state at the time it's made, so once closed call Idle() again, after
+// queuing more work, to wait for the pool to next become idle. A closed pool is idle once it's
+// running Work Units finish, or are abandoned.
+func (p *Pool) Idle() <-chan struct{} {
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.isIdle() {
+		return closedCh
+	}
+
+	if p.idleCh == nil {
+		p.idleCh = make(chan struct{})
+	}
+
+	return p.idleCh
+}
+
+// closedCh is returned by Idle() when the pool is already idle, and CanQueue() when there's room.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
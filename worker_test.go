@@ -0,0 +1,344 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/go-playground/assert.v1"
+)
+
+func TestNewWithWorkerState(t *testing.T) {
+
+	type state struct {
+		inUse int32
+		runs  int
+	}
+
+	var created int32
+	var shared int32
+
+	pool := NewWithWorkerState(2, func() interface{} {
+		atomic.AddInt32(&created, 1)
+		return new(state)
+	})
+	defer pool.Close()
+
+	fn := func(s interface{}) (interface{}, error) {
+
+		st := s.(*state)
+
+		if !atomic.CompareAndSwapInt32(&st.inUse, 0, 1) {
+			atomic.AddInt32(&shared, 1)
+		}
+
+		st.runs++
+		atomic.StoreInt32(&st.inUse, 0)
+
+		return st, nil
+	}
+
+	var res []*WorkUnit
+
+	for i := 0; i < 100; i++ {
+		res = append(res, pool.QueueWithState(fn))
+	}
+
+	WaitAll(res...)
+
+	Equal(t, atomic.LoadInt32(&created) <= 2, true)
+	Equal(t, atomic.LoadInt32(&shared), int32(0))
+
+	states := make(map[*state]struct{})
+
+	for _, wu := range res {
+		states[wu.Value.(*state)] = struct{}{}
+	}
+
+	var runs int
+
+	for s := range states {
+		runs += s.runs
+	}
+
+	Equal(t, runs, 100)
+
+	// returns the state of each worker, each Work Unit is held until both are running
+	both := func() map[interface{}]struct{} {
+
+		var started sync.WaitGroup
+		started.Add(2)
+
+		held := func(s interface{}) (interface{}, error) {
+			started.Done()
+			started.Wait()
+			return s, nil
+		}
+
+		a, b := pool.QueueWithState(held), pool.QueueWithState(held)
+		WaitAll(a, b)
+
+		return map[interface{}]struct{}{a.Value: {}, b.Value: {}}
+	}
+
+	before := both()
+	Equal(t, len(before), 2)
+	Equal(t, atomic.LoadInt32(&created), int32(2))
+
+	// state is discarded and recreated after a panic, only that of the worker that panicked
+	wu := pool.QueueWithState(func(s interface{}) (interface{}, error) {
+		panic("OMG")
+	})
+	<-wu.Done
+
+	after := both()
+	Equal(t, len(after), 2)
+	Equal(t, atomic.LoadInt32(&created), int32(3))
+
+	var kept int
+
+	for s := range after {
+		if _, ok := before[s]; ok {
+			kept++
+		}
+	}
+
+	Equal(t, kept, 1)
+
+	// units without state on a regular pool get nil
+	p2 := New(1)
+	defer p2.Close()
+
+	wu = p2.QueueWithState(func(s interface{}) (interface{}, error) {
+		return s, nil
+	})
+	<-wu.Done
+
+	Equal(t, wu.Value, nil)
+}
+
+func TestOnWorkerStartStop(t *testing.T) {
+
+	pool := New(2)
+	defer pool.Close()
+
+	var m sync.Mutex
+	started := make(map[int]int)
+	stopped := make(map[int]int)
+
+	pool.OnWorkerStart(func(id int) {
+		m.Lock()
+		started[id]++
+		m.Unlock()
+	})
+
+	pool.OnWorkerStop(func(id int) {
+		m.Lock()
+		stopped[id]++
+		m.Unlock()
+	})
+
+	// workers started before the hook was set are notified before their next Work Unit
+	var res []*WorkUnit
+
+	for i := 0; i < 20; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Millisecond)
+			return nil, nil
+		}))
+	}
+
+	WaitAll(res...)
+
+	// shrinking stops workers
+	pool.Resize(3)
+	pool.Resize(1)
+
+	count := func(m *sync.Mutex, counts map[int]int) int {
+		m.Lock()
+		defer m.Unlock()
+
+		var n int
+
+		for _, c := range counts {
+			n += c
+		}
+
+		return n
+	}
+
+	for count(&m, stopped) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// cancelling stops the rest
+	pool.Cancel()
+
+	for count(&m, stopped) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	m.Lock()
+
+	Equal(t, len(stopped), 3)
+
+	// started at most once
+	for _, c := range started {
+		Equal(t, c, 1)
+	}
+
+	for id, c := range stopped {
+		Equal(t, c, 1)
+		Equal(t, id >= 1 && id <= 3, true)
+	}
+
+	m.Unlock()
+
+	// new workers get new IDs
+	pool.Reset()
+	pool.Cancel()
+
+	for count(&m, stopped) != 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	m.Lock()
+	_, ok := stopped[4]
+	m.Unlock()
+
+	Equal(t, ok, true)
+}
+
+func TestWorkerCount(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	waitFor := func(n int) {
+		for pool.WorkerCount() != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor(4)
+
+	// surplus workers exit once they've finished their current Work Unit
+	block := make(chan struct{})
+	var res []*WorkUnit
+
+	for i := 0; i < 4; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			<-block
+			return nil, nil
+		}))
+	}
+
+	for pool.Stats().RunningCount != 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	pool.Resize(1)
+
+	time.Sleep(time.Millisecond * 10)
+	Equal(t, pool.WorkerCount(), 4)
+
+	close(block)
+	WaitAll(res...)
+
+	waitFor(1)
+
+	pool.Resize(3)
+	waitFor(3)
+
+	// a worker replaced after a panic
+	wu := pool.Queue(func() (interface{}, error) {
+		panic("oops")
+	})
+	<-wu.Done
+
+	waitFor(3)
+
+	pool.Close()
+	waitFor(0)
+}
+
+func TestIdle(t *testing.T) {
+
+	pool := New(4)
+	defer pool.Close()
+
+	// already idle
+	<-pool.Idle()
+
+	fn := func() (interface{}, error) {
+		return nil, nil
+	}
+
+	for i := 0; i < 1000; i++ {
+
+		wu := pool.Queue(fn)
+
+		<-pool.Idle()
+		Equal(t, wu.IsDone(), true)
+	}
+
+	// idle only once all of the work is done
+	var res []*WorkUnit
+
+	for i := 0; i < 100; i++ {
+		res = append(res, pool.Queue(func() (interface{}, error) {
+			time.Sleep(time.Microsecond * 100)
+			return nil, nil
+		}))
+	}
+
+	<-pool.Idle()
+
+	for _, wu := range res {
+		Equal(t, wu.IsDone(), true)
+	}
+
+	// racing producers and waiters
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				wu := pool.Queue(fn)
+				<-wu.Done
+				<-pool.Idle()
+			}
+		}()
+	}
+
+	wg.Wait()
+	<-pool.Idle()
+
+	// a closed pool is idle once it's running Work Units finish
+	block := make(chan struct{})
+
+	wu := pool.Queue(func() (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	for pool.Stats().RunningCount != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	idle := pool.Idle()
+	pool.Close()
+
+	select {
+	case <-idle:
+		t.Fatal("idle whilst still running")
+	default:
+	}
+
+	close(block)
+	<-wu.Done
+	<-idle
+}